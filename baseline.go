@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// fingerprint identifies a finding by what it's about rather than where it
+// is, so moving code around doesn't make a baselined finding new again.  The
+// passes' diagnostics don't say which emitter or type, so for those it's the
+// message, which doesn't have a position in it either.  The emitter is just
+// its field name, `userEvent` not `s.userEvent`, so renaming a receiver
+// doesn't do it either.
+func fingerprint(f Finding) string {
+	if f.Emitter == "" && f.EmittedType == "" {
+		return strings.Join([]string{f.Category, f.Pkg, f.Message}, "|")
+	}
+	return strings.Join([]string{
+		f.Category,
+		f.Pkg,
+		f.Emitter[strings.LastIndex(f.Emitter, ".")+1:],
+		f.EmittedType,
+		strings.Join(f.DeclaredTypes, ","),
+		strings.Join(f.Events, ","),
+	}, "|")
+}
+
+// baseline is how many findings there were with each fingerprint.  Counting
+// means a second copy of a baselined mismatch in the same package still gets
+// reported.
+type baseline map[string]int
+
+// writeBaseline records `findings` as the baseline, sorted so it diffs clean
+// when it's checked in.
+func writeBaseline(w io.Writer, findings []Finding) error {
+	b := make(baseline)
+	b.add(findings)
+	return b.write(w)
+}
+
+// add counts `findings` into the baseline.
+func (b baseline) add(findings []Finding) {
+	for _, f := range findings {
+		b[fingerprint(f)]++
+	}
+}
+
+// write writes the baseline out the way `readBaseline` reads it.
+func (b baseline) write(w io.Writer) error {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	type entry struct {
+		Fingerprint string `json:"fingerprint"`
+		Count       int    `json:"count"`
+	}
+	entries := make([]entry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, entry{k, b[k]})
+	}
+	out, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+// readBaseline reads a file written by `writeBaseline`.
+func readBaseline(path string) (baseline, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Fingerprint string `json:"fingerprint"`
+		Count       int    `json:"count"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, errors.Wrapf(err, "reading baseline %s", path)
+	}
+	b := make(baseline, len(entries))
+	for _, e := range entries {
+		b[e.Fingerprint] += e.Count
+	}
+	return b, nil
+}
+
+// suppress drops the findings the baseline already has, up to as many of each
+// as it recorded, and says how many went.  Findings are in position order so
+// it's the later copies of a fingerprint that get through.
+func (b baseline) suppress(findings []Finding) ([]Finding, int) {
+	left := make(baseline, len(b))
+	for k, n := range b {
+		left[k] = n
+	}
+	var out []Finding
+	dropped := 0
+	for _, f := range findings {
+		if k := fingerprint(f); left[k] > 0 {
+			left[k]--
+			dropped++
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, dropped
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	f := Finding{
+		Category:      string(KindMismatch),
+		Pkg:           "services",
+		Emitter:       "s.userEvent",
+		EmittedType:   "types.Order",
+		DeclaredTypes: []string{"types.UserSettings"},
+		Events:        []string{"types.EventPathUserAccountSettings"},
+		Message:       "s.userEvent emits types.Order, wants types.UserSettings",
+		File:          "services.go",
+		Line:          12,
+	}
+	moved := f
+	moved.Emitter = "svc.userEvent"
+	moved.Message = "svc.userEvent emits types.Order, wants types.UserSettings"
+	moved.File, moved.Line = "other.go", 40
+	if fingerprint(f) != fingerprint(moved) {
+		t.Errorf("renaming the receiver and moving the call changed the fingerprint:\n%s\n%s", fingerprint(f), fingerprint(moved))
+	}
+	other := f
+	other.Emitter = "s.orderEvent"
+	if fingerprint(f) == fingerprint(other) {
+		t.Errorf("a different emitter has the same fingerprint %s", fingerprint(f))
+	}
+}
+
+func TestBaselineSuppress(t *testing.T) {
+	f := Finding{Category: string(KindMismatch), Pkg: "services", Emitter: "s.userEvent", EmittedType: "types.Order"}
+	b := baseline{fingerprint(f): 1}
+	out, dropped := b.suppress([]Finding{f, f})
+	if dropped != 1 || len(out) != 1 {
+		t.Errorf("suppress dropped %d and kept %d, want 1 and 1", dropped, len(out))
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the `-config` file.  Unlike the baseline it's written by people,
+// so it's strict about what's in it.
+type config struct {
+	AllowedMismatches []allowedMismatch `json:"allowed_mismatches" yaml:"allowed_mismatches"`
+}
+
+// allowedMismatch is an emitter that's meant to emit something its event's
+// hint doesn't say, eg. a legacy `userEvent` that still sends `types.Order`
+// for `types.UserSettings`.  Types are written like hints and compare the same
+// way, the emitter can leave the receiver off.
+type allowedMismatch struct {
+	Emitter      string `json:"emitter" yaml:"emitter"`
+	DeclaredType string `json:"declaredType" yaml:"declaredType"`
+	EmittedType  string `json:"emittedType" yaml:"emittedType"`
+}
+
+// readConfig reads and checks a `-config` file, YAML if it's called `.yaml`
+// or `.yml` and JSON otherwise.  Keys we don't know are an error rather than
+// a typo that quietly allows nothing.
+func readConfig(path string) (config, error) {
+	var c config
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		// An empty file is an empty config.
+		if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+			return c, errors.Wrapf(err, "reading config %s", path)
+		}
+	default:
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return c, errors.Wrapf(err, "reading config %s", path)
+		}
+	}
+	for n, a := range c.AllowedMismatches {
+		if a.Emitter == "" || a.DeclaredType == "" || a.EmittedType == "" {
+			return c, fmt.Errorf("config %s: allowed_mismatches[%d] needs an emitter, declaredType and emittedType", path, n)
+		}
+	}
+	return c, nil
+}
+
+// allows reports whether the entry covers a mismatch finding.
+func (a allowedMismatch) allows(f Finding) bool {
+	if f.Category != string(KindMismatch) {
+		return false
+	}
+	if f.Emitter != a.Emitter && !strings.HasSuffix(f.Emitter, "."+a.Emitter) {
+		return false
+	}
+	if !typesMatch(a.EmittedType, f.EmittedType) {
+		return false
+	}
+	for _, d := range f.DeclaredTypes {
+		if d == a.DeclaredType || shortType(d) == shortType(a.DeclaredType) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed drops the mismatches the config says are intentional.
+func (c config) allowed(findings []Finding) []Finding {
+	if len(c.AllowedMismatches) == 0 {
+		return findings
+	}
+	var out []Finding
+	for _, f := range findings {
+		keep := true
+		for _, a := range c.AllowedMismatches {
+			if a.allows(f) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, f)
+		}
+	}
+	return out
+}
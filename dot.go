@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// writeDOT writes a graphviz digraph of who emits what: packages own their
+// emitters, emitters point at the types their events' hints name, and call
+// sites point at the emitter they go through.  Node IDs are the qualified names
+// so nothing collides across packages, the labels are the short ones.  It's
+// meant for `dot -Tsvg`.
+func writeDOT(w io.Writer, emitters []Emitter, j Join) error {
+	type key struct{ pkg, name string }
+	var order []key
+	seen := make(map[key]bool)
+	for _, e := range emitters {
+		k := key{e.Pkg, e.Name}
+		if !seen[k] {
+			seen[k] = true
+			order = append(order, k)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].pkg != order[j].pkg {
+			return order[i].pkg < order[j].pkg
+		}
+		return order[i].name < order[j].name
+	})
+
+	var b strings.Builder
+	b.WriteString("digraph emitters {\n\trankdir=LR;\n\tnode [fontname=\"monospace\"];\n")
+	pkgs := make(map[string]bool)
+	hints := make(map[string]bool)
+	for _, k := range order {
+		if !pkgs[k.pkg] {
+			pkgs[k.pkg] = true
+			fmt.Fprintf(&b, "\t%q [shape=box3d, label=%q];\n", "pkg:"+k.pkg, k.pkg)
+		}
+		id := "emitter:" + k.pkg + "." + k.name
+		fmt.Fprintf(&b, "\t%q [shape=box, label=%q];\n", id, k.name)
+		fmt.Fprintf(&b, "\t%q -> %q [label=\"has\"];\n", "pkg:"+k.pkg, id)
+		for _, ev := range j.Events(k.pkg, k.name) {
+			// An event without a hint still gets a node, it's just one we
+			// can't say anything about.
+			t := "?"
+			if c, ok := j.Consts[ev]; ok && !noHint(c.Hint) {
+				t = c.resolvedHint()
+			}
+			if !hints[t] {
+				hints[t] = true
+				fmt.Fprintf(&b, "\t%q [shape=ellipse, label=%q];\n", "type:"+t, shortType(t))
+			}
+			fmt.Fprintf(&b, "\t%q -> %q [label=%q];\n", id, "type:"+t, shortType(ev))
+		}
+	}
+
+	for _, jc := range j.Calls {
+		call := jc.Call
+		id := "emitter:" + call.Pkg + "." + call.Emitter
+		if !seen[key{call.Pkg, call.Emitter}] {
+			// Calls through something we never saw bound would only add
+			// dangling nodes.
+			continue
+		}
+		site := fmt.Sprintf("%s:%d", relPath(call.Pos.Filename), call.Pos.Line)
+		fmt.Fprintf(&b, "\t%q [shape=note, label=%q];\n", "call:"+call.Pos.String(), site)
+		fmt.Fprintf(&b, "\t%q -> %q [label=\"uses\"];\n", "call:"+call.Pos.String(), id)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
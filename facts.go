@@ -0,0 +1,353 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// eventFact goes on an event constant so packages using it know its hint
+// without waiting for the join.
+type eventFact struct {
+	Name     string // qualified by import path, like `EventConst.Name`
+	Value    string // the event string itself
+	Hint     string
+	HintPath string
+}
+
+func (*eventFact) AFact() {}
+
+func (f *eventFact) String() string { return f.Name + " " + f.Value + " " + f.Hint }
+
+// emitterFact goes on an emitter field or package-level var and has the
+// events it's bound to, so a call through it can be checked wherever it is.
+// An emitter method gets one too, with a made-up event for its own hint.
+type emitterFact struct {
+	Events []eventFact
+}
+
+func (*emitterFact) AFact() {}
+
+func (f *emitterFact) String() string {
+	s := ""
+	for _, e := range f.Events {
+		s += "[" + e.String() + "]"
+	}
+	return s
+}
+
+// bindFact adds the event constant `arg` refers to, if we know about it, to
+// the bindings on `v`.  Only vars of this package can carry our facts.
+func bindFact(pass *analysis.Pass, v *types.Var, arg ast.Expr) {
+	if v == nil || v.Pkg() != pass.Pkg {
+		return
+	}
+	c := constObj(pass, arg)
+	if c == nil {
+		return
+	}
+	var ev eventFact
+	if !pass.ImportObjectFact(c, &ev) {
+		return
+	}
+	var ef emitterFact
+	pass.ImportObjectFact(v, &ef)
+	for _, e := range ef.Events {
+		if e.Name == ev.Name {
+			return
+		}
+	}
+	ef.Events = append(ef.Events, ev)
+	pass.ExportObjectFact(v, &ef)
+}
+
+// factCall is an emitter call waiting to be checked against the facts on its
+// emitter once every binding in the package has been seen.
+type factCall struct {
+	Obj  types.Object // the field, var or method the call goes through
+	Call CallSite
+	Pos  token.Pos
+}
+
+// checkFactCall reports a call whose resolved payload matches none of the
+// hinted events bound to its emitter.  It's the same verdict the join comes to
+// for the same call, reached from the facts alone, so the analyzer is some use
+// under other drivers too.  Lines in `ignore` are left alone.
+func checkFactCall(pass *analysis.Pass, fc factCall, ignore map[lineKey]bool) {
+	var ef emitterFact
+	if fc.Call.Type == "" || ignoredAt(ignore, fc.Call.Pos) || !pass.ImportObjectFact(fc.Obj, &ef) {
+		return
+	}
+	m := Mismatch{Kind: KindMismatch, Call: fc.Call}
+	for _, e := range ef.Events {
+		m.Events = append(m.Events, e.Name)
+		if noHint(e.Hint) {
+			continue
+		}
+		c := EventConst{Name: e.Name, Hint: e.Hint, HintPath: e.HintPath}
+		if c.accepts(fc.Call.Type) {
+			return
+		}
+		m.Wants = append(m.Wants, c)
+	}
+	if len(m.Wants) == 0 {
+		return
+	}
+	pass.Report(analysis.Diagnostic{Pos: fc.Pos, Category: string(KindMismatch), Message: m.String()})
+}
+
+// emitterVar gives the struct field or package-level var a selector, key or
+// name picks out, or nil.  `services.userEvent` from another package counts,
+// which is how a package-level emitter gets checked across packages.
+func emitterVar(pass *analysis.Pass, e ast.Expr) *types.Var {
+	switch x := e.(type) {
+	case *ast.SelectorExpr:
+		if sel, ok := pass.TypesInfo.Selections[x]; ok {
+			if sel.Kind() != types.FieldVal {
+				return nil
+			}
+			v, _ := sel.Obj().(*types.Var)
+			return v
+		}
+		return emitterVar(pass, x.Sel)
+	case *ast.Ident:
+		if v, ok := pass.TypesInfo.ObjectOf(x).(*types.Var); ok && (v.IsField() || isPackageLevel(v)) {
+			return v
+		}
+	}
+	return nil
+}
+
+// calledVar is `emitterVar` for what a call calls, where a local copy of an
+// emitter field or var stands in for the original.
+func calledVar(pass *analysis.Pass, fun ast.Expr) *types.Var {
+	if v := emitterVar(pass, fun); v != nil {
+		return v
+	}
+	if o, err := localOrigin(pass, fun); err == nil {
+		return emitterVar(pass, o)
+	}
+	return nil
+}
+
+// isPackageLevel reports whether `v` is declared at package scope.
+func isPackageLevel(v *types.Var) bool {
+	return v.Pkg() != nil && v.Parent() == v.Pkg().Scope()
+}
+
+// emitterMethod exports the fact for a method written as an emitter,
+// `func (s *svc) userEvent(evt rabbitEvents.EventType, args ...interface{}) error`,
+// ie. one whose first parameter is the events package's `EventType`.  What it
+// emits comes from its doc comment, `userEvent emits types.UserSettings.`, or
+// failing that from its last parameter, or the one before any functional
+// options, if that's a concrete type.  The event is named after the method,
+// eg. `svc.userEvent`, since there's no constant.
+func emitterMethod(pass *analysis.Pass, fd *ast.FuncDecl) {
+	if fd.Recv == nil {
+		return
+	}
+	fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+	if !isEmitterSignature(sig) {
+		return
+	}
+	hint := docHint(fd.Doc)
+	if hint == "" {
+		last := sig.Params().At(sig.Params().Len() - 1).Type()
+		if n, ok := optionsParam(sig); ok {
+			last = sig.Params().At(n - 1).Type()
+		} else if sig.Variadic() {
+			last = last.(*types.Slice).Elem()
+		}
+		if types.IsInterface(last) {
+			return
+		}
+		if hint = typeName(last); hint == "" {
+			return
+		}
+	}
+	recv := sig.Recv().Type()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	name := fd.Name.Name
+	if n, ok := types.Unalias(recv).(*types.Named); ok {
+		name = n.Obj().Name() + "." + name
+	}
+	pass.ExportObjectFact(fn, &emitterFact{Events: []eventFact{{Name: name, Hint: hint}}})
+}
+
+// docHint finds the type after `emits` in a doc comment, eg.
+// `types.UserSettings` in `userEvent emits types.UserSettings.`
+func docHint(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	words := strings.Fields(doc.Text())
+	for n := 0; n < len(words)-1; n++ {
+		if strings.EqualFold(words[n], "emits") {
+			hint := strings.TrimRight(strings.Trim(words[n+1], "`'\"(),;:"), ".")
+			if hintPattern.MatchString(hint) {
+				return hint
+			}
+		}
+	}
+	return ""
+}
+
+// isEmitterSignature reports whether a method is written as an emitter, its
+// first parameter the events package's `EventType` and something after it.
+func isEmitterSignature(sig *types.Signature) bool {
+	return sig.Params().Len() >= 2 && isEventType(sig.Params().At(0).Type())
+}
+
+// isEmitterCall reports whether a call goes through an emitter: a field or
+// var of the `EventEmitter` type, a local copy of one, or a method written as
+// an emitter, an interface's included.  It's by type, since the method's facts
+// may not have been exported yet when we get to the call.
+func isEmitterCall(pass *analysis.Pass, fun ast.Expr) bool {
+	if v := calledVar(pass, fun); v != nil && isEmitterType(v.Type()) {
+		return true
+	}
+	se, ok := ast.Unparen(fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := pass.TypesInfo.Selections[se]
+	if !ok || sel.Kind() != types.MethodVal {
+		return false
+	}
+	sig, ok := sel.Obj().Type().(*types.Signature)
+	return ok && isEmitterSignature(sig)
+}
+
+// isEventType reports whether `t` is the events package's `EventType`.
+func isEventType(t types.Type) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Name() == "EventType" && isEventsPackage(n.Obj().Pkg())
+}
+
+// calledMethod gives the method a call goes through if it's a concrete one,
+// not one promoted from an interface, or nil.
+func calledMethod(pass *analysis.Pass, fun ast.Expr) *types.Func {
+	se, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := pass.TypesInfo.Selections[se]
+	if !ok || sel.Kind() != types.MethodVal {
+		return nil
+	}
+	fn, _ := sel.Obj().(*types.Func)
+	return fn
+}
+
+// ifaceEmitterField gives the field or package-level var an `Emit` call goes
+// through when it's an interface declaring one, `s.em.Emit(...)` with
+// `em interface { Emit(evt rabbitEvents.EventType, args ...interface{}) error }`,
+// or nil.  What it really emits depends on what's been put in it.
+func ifaceEmitterField(pass *analysis.Pass, fun ast.Expr) *types.Var {
+	se, ok := fun.(*ast.SelectorExpr)
+	if !ok || se.Sel.Name != "Emit" {
+		return nil
+	}
+	sel, ok := pass.TypesInfo.Selections[se]
+	if !ok || sel.Kind() != types.MethodVal || !types.IsInterface(sel.Recv()) {
+		return nil
+	}
+	if !isEmitterSignature(sel.Obj().Type().(*types.Signature)) {
+		return nil
+	}
+	return emitterVar(pass, se.X)
+}
+
+// implementations gives the concrete types this package puts in each
+// interface-typed field or package-level var, by struct literal key,
+// assignment or declaration, in the order they turn up.
+func implementations(pass *analysis.Pass) map[*types.Var][]types.Type {
+	impls := make(map[*types.Var][]types.Type)
+	add := func(v *types.Var, e ast.Expr) {
+		if v == nil || !types.IsInterface(v.Type()) {
+			return
+		}
+		t := pass.TypesInfo.TypeOf(e)
+		if t == nil || types.IsInterface(t) {
+			return
+		}
+		for _, have := range impls[v] {
+			if types.Identical(have, t) {
+				return
+			}
+		}
+		impls[v] = append(impls[v], t)
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.KeyValueExpr:
+				if i, ok := x.Key.(*ast.Ident); ok {
+					add(emitterVar(pass, i), x.Value)
+				}
+			case *ast.AssignStmt:
+				if len(x.Lhs) == len(x.Rhs) {
+					for k, lhs := range x.Lhs {
+						add(emitterVar(pass, lhs), x.Rhs[k])
+					}
+				}
+			case *ast.ValueSpec:
+				if len(x.Names) == len(x.Values) {
+					for k, name := range x.Names {
+						add(emitterVar(pass, name), x.Values[k])
+					}
+				}
+			}
+			return true
+		})
+	}
+	return impls
+}
+
+// implEmitter gives what a call through an interface emitter should be
+// checked against once we know the one concrete type behind it: its `Emit`
+// method if that's written as an emitter, or else its one `EventEmitter`
+// field, which `Emit` presumably hands the call to.  It's nil if there's more
+// than one type, or nothing like that on the one there is.
+func implEmitter(pass *analysis.Pass, impls []types.Type) types.Object {
+	if len(impls) != 1 {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(impls[0], true, pass.Pkg, "Emit")
+	if fn, ok := obj.(*types.Func); ok {
+		var ef emitterFact
+		if pass.ImportObjectFact(fn, &ef) {
+			return fn
+		}
+	}
+	t := impls[0]
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var field *types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if isEmitterType(st.Field(i).Type()) {
+			if field != nil {
+				return nil
+			}
+			field = st.Field(i)
+		}
+	}
+	if field == nil {
+		return nil
+	}
+	return field
+}
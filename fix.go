@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+
+	"github.com/pkg/errors"
+)
+
+// hintFixes turns inferred hints into suggested fixes, each one a comment on
+// the end of the constant's line.  They come out of the join rather than a
+// pass, since the type is whatever other packages emit, so `-fix` applies
+// them itself.
+func hintFixes(inferred []InferredHint) []analysis.SuggestedFix {
+	fixes := make([]analysis.SuggestedFix, 0, len(inferred))
+	for _, h := range inferred {
+		fixes = append(fixes, analysis.SuggestedFix{
+			Message:   fmt.Sprintf("add type hint %s to %s", h.Hint, shortType(h.Const.Name)),
+			TextEdits: []analysis.TextEdit{{Pos: h.Const.end, End: h.Const.end, NewText: []byte(" // " + h.Hint)}},
+		})
+	}
+	return fixes
+}
+
+// diagnosticFixes gives the fixes the passes attached to their diagnostics,
+// which are mostly the join's too but not always.
+func diagnosticFixes(graph *checker.Graph) []analysis.SuggestedFix {
+	var fixes []analysis.SuggestedFix
+	for _, act := range graph.Roots {
+		for _, d := range act.Diagnostics {
+			fixes = append(fixes, d.SuggestedFixes...)
+		}
+	}
+	return fixes
+}
+
+// suggestHints fills in `SuggestedHint` on the missing-hint findings we have
+// an inferred hint for.
+func suggestHints(findings []Finding, inferred []InferredHint) {
+	byPos := make(map[token.Position]string, len(inferred))
+	for _, h := range inferred {
+		byPos[token.Position{Filename: h.Const.Pos.Filename, Line: h.Const.Pos.Line, Column: h.Const.Pos.Column}] = h.Hint
+	}
+	for i, f := range findings {
+		if f.Category != catMissingHint {
+			continue
+		}
+		if hint, ok := byPos[token.Position{Filename: f.File, Line: f.Line, Column: f.Col}]; ok {
+			findings[i].SuggestedHint = hint
+		}
+	}
+}
+
+// findingFixes gives each finding's fix, nil for the ones we haven't got one
+// for, which is all but the missing hints we could infer.
+func findingFixes(findings []Finding, inferred []InferredHint) []*analysis.SuggestedFix {
+	fixes := hintFixes(inferred)
+	byPos := make(map[token.Position]*analysis.SuggestedFix, len(inferred))
+	for n, h := range inferred {
+		byPos[token.Position{Filename: h.Const.Pos.Filename, Line: h.Const.Pos.Line, Column: h.Const.Pos.Column}] = &fixes[n]
+	}
+	out := make([]*analysis.SuggestedFix, len(findings))
+	for n, f := range findings {
+		if f.Category == catMissingHint {
+			out[n] = byPos[token.Position{Filename: f.File, Line: f.Line, Column: f.Col}]
+		}
+	}
+	return out
+}
+
+// applyFixes writes the fixes' edits into the files they're for, back to
+// front in each file so the earlier offsets still hold, and nothing else: the
+// rest of the file is left as it was.  The same edit from two fixes is made
+// once, since the passes and the join can both suggest a hint.  Edits that
+// overlap are a bug on our end and we stop before writing that file.  Each
+// file is written to a temporary file next to it and renamed over it, so it's
+// never half written.  It says how many edits it made.
+func applyFixes(fset *token.FileSet, fixes []analysis.SuggestedFix) (int, error) {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	byFile := make(map[string][]edit)
+	seen := make(map[string]map[edit]bool)
+	for _, fx := range fixes {
+		for _, te := range fx.TextEdits {
+			start, end := fset.Position(te.Pos), fset.Position(te.End)
+			e := edit{start.Offset, end.Offset, string(te.NewText)}
+			if seen[start.Filename] == nil {
+				seen[start.Filename] = make(map[edit]bool)
+			}
+			if seen[start.Filename][e] {
+				continue
+			}
+			seen[start.Filename][e] = true
+			byFile[start.Filename] = append(byFile[start.Filename], e)
+		}
+	}
+	files := make([]string, 0, len(byFile))
+	for name := range byFile {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	applied := 0
+	for _, name := range files {
+		edits := byFile[name]
+		sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+		for i := 1; i < len(edits); i++ {
+			if edits[i].end > edits[i-1].start {
+				return 0, fmt.Errorf("%s: overlapping edits at offsets %d and %d", name, edits[i].start, edits[i-1].start)
+			}
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return 0, err
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range edits {
+			if e.end > len(src) {
+				return 0, fmt.Errorf("%s: edit past the end of the file, has it changed?", name)
+			}
+			src = append(src[:e.start], append([]byte(e.text), src[e.end:]...)...)
+		}
+		if err := writeAtomic(name, src, info.Mode()); err != nil {
+			return 0, errors.Wrapf(err, "applying fixes to %s", name)
+		}
+		applied += len(edits)
+	}
+	return applied, nil
+}
+
+// writeAtomic replaces `name` with `src` by way of a temporary file in the
+// same directory, so a rename is all anyone else ever sees.
+func writeAtomic(name string, src []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	// Gone by the time we're done, one way or the other.
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
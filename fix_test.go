@@ -0,0 +1,49 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// TestApplyFixes checks only the edits get made, once each however many fixes
+// have them, and the rest of the file is left exactly as it was.
+func TestApplyFixes(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "events.go")
+	// Not gofmt'd, and it has to stay that way.
+	src := "package events\n\nconst (\n\tEventA = \"a\"\n\tEventLonger   =   \"longer\" // types.B\n)\n"
+	if err := os.WriteFile(name, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f := fset.AddFile(name, -1, len(src))
+	f.SetLinesForContent([]byte(src))
+	end := f.Pos(len("package events\n\nconst (\n\tEventA = \"a\""))
+	fx := analysis.SuggestedFix{TextEdits: []analysis.TextEdit{{Pos: end, End: end, NewText: []byte(" // types.A")}}}
+
+	n, err := applyFixes(fset, []analysis.SuggestedFix{fx, fx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("applied %d edits, want 1", n)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package events\n\nconst (\n\tEventA = \"a\" // types.A\n\tEventLonger   =   \"longer\" // types.B\n)\n"
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("mode is %v, %v, want 0600", info.Mode(), err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, ".*")); len(left) != 0 {
+		t.Errorf("temporary files left behind: %v", left)
+	}
+}
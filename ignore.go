@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// lineKey is a line of a file, which is as precise as a directive gets.
+type lineKey struct {
+	File string
+	Line int
+}
+
+// ignoredLines has every line a directive silences, across the root
+// packages, for the findings the passes don't report themselves.  `mux`
+// guards it.
+var ignoredLines = make(map[lineKey]bool)
+
+// directiveLines finds the `-ignore-directives` comments in `file` and gives
+// the lines they silence: the comment's own line, for a trailing one, and the
+// line after, for one on a line of its own above the code.  It's the line the
+// finding is reported on that counts, which for a call split over several
+// lines is the one with its opening parenthesis, and for a constant the line
+// it's declared on.
+func directiveLines(pass *analysis.Pass, file *ast.File) map[lineKey]bool {
+	lines := make(map[lineKey]bool)
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if !isIgnoreDirective(c.Text) {
+				continue
+			}
+			pos := pass.Fset.Position(c.Slash)
+			lines[lineKey{pos.Filename, pos.Line}] = true
+			lines[lineKey{pos.Filename, pos.Line + 1}] = true
+		}
+	}
+	return lines
+}
+
+// isIgnoreDirective reports whether a comment is one of `-ignore-directives`.
+// A `name:value` directive matches a comment with `value` anywhere in its
+// list, so `//nolint:errcheck,emitteranalysis // we mean it` counts for
+// `nolint:emitteranalysis`.  Anything after the directive is a reason and
+// doesn't matter.
+func isIgnoreDirective(text string) bool {
+	fields := strings.Fields(strings.TrimPrefix(text, "//"))
+	if len(fields) == 0 {
+		return false
+	}
+	for _, d := range strings.Split(*ignoreDirectives, ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		name, value, isList := strings.Cut(d, ":")
+		if !isList {
+			if fields[0] == d {
+				return true
+			}
+			continue
+		}
+		got, list, ok := strings.Cut(fields[0], ":")
+		if !ok || got != name {
+			continue
+		}
+		for _, v := range strings.Split(list, ",") {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignoredAt reports whether a directive silences what's at `pos`.
+func ignoredAt(lines map[lineKey]bool, pos token.Position) bool {
+	return lines[lineKey{pos.Filename, pos.Line}]
+}
+
+// unignored drops the findings a directive silences.
+func unignored(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if !ignoredLines[lineKey{f.File, f.Line}] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,966 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// noHint reports whether a hint is really the lack of one: empty, or the
+// `-unknown-type` sentinel we give constants without a comment.
+func noHint(hint string) bool {
+	return hint == "" || hint == *unknownType
+}
+
+// hintPattern is what a usable type hint looks like, eg. `types.UserSettings`
+// or, path-qualified, `github.com/org/types.UserSettings`.  Builtins and the
+// standard library are fine too, `string`, `[]byte` or `time.Time`.
+var hintPattern = regexp.MustCompile(`^(\[\]|\*)*([\pL\pN_.~-]+/)*[\pL_][\pL\pN_-]*(\.[\pL_][\pL\pN_]*)*$`)
+
+// parseHint splits a stripped const comment into its type hint and whether it
+// also says the event is deprecated, eg. `types.UserSettings (deprecated)`.
+// Anything after another `//` is a comment on the comment and not ours.
+func parseHint(text string) (string, bool) {
+	text, _, _ = strings.Cut(text, "//")
+	var hint []string
+	deprecated := false
+	for _, f := range strings.Fields(text) {
+		if strings.EqualFold(strings.Trim(f, "()[],:;"), "deprecated") {
+			deprecated = true
+			continue
+		}
+		hint = append(hint, f)
+	}
+	return strings.Join(hint, " "), deprecated
+}
+
+// accepts reports whether an emitted type satisfies the constant's hint.  Once
+// the hint's package has been resolved against the file's imports we know
+// exactly which `types` it means, so it compares on the whole path whatever
+// `-pkg-match` says.
+func (c EventConst) accepts(emitted string) bool {
+	if c.HintPath == "" || c.HintPath == c.Hint {
+		return typesMatch(c.Hint, emitted)
+	}
+	return matchTypes(c.HintPath, emitted, samePkg)
+}
+
+// resolvedHint is `HintPath` if we have it and `Hint` if we don't.
+func (c EventConst) resolvedHint() string {
+	if c.HintPath != "" {
+		return c.HintPath
+	}
+	return c.Hint
+}
+
+// shortNames joins qualified names for a message, each cut down to its
+// package's last path element the way `shortType` does types.
+func shortNames(names []string) string {
+	short := make([]string, 0, len(names))
+	for _, n := range names {
+		short = append(short, shortType(n))
+	}
+	return strings.Join(short, ", ")
+}
+
+// hasValidHint reports whether a constant's comment gave us a type we can use.
+func hasValidHint(c EventConst) bool {
+	return !noHint(c.Hint) && hintPattern.MatchString(c.Hint)
+}
+
+// EventConst is an `Event*` constant and the type its comment says it carries.
+type EventConst struct {
+	Pkg        string // import path of the declaring package
+	Name       string // qualified by import path, eg. `github.com/org/types.EventPathUserAccountSettings`
+	Value      string // the event string itself
+	Hint       string // eg. `types.UserSettings`, as written
+	HintPath   string // `Hint` with its package resolved to an import path where we could
+	Dangling   bool   // the hint looks fine but names a type that doesn't exist
+	Deprecated bool   // marked deprecated, so nothing new should emit it
+	Pos        token.Position
+	HintPos    token.Position // where the hint's comment is, if it has one
+
+	end token.Pos // where a hint would go, for comment-less constants only
+}
+
+// Emitter is one binding of an emitter to the constant it was created with.
+// The same emitter can have several of these.
+type Emitter struct {
+	Pkg   string // import path of the package doing the binding
+	Name  string // eg. `userEvent`
+	Event string // qualified constant, eg. `types.EventPathUserAccountSettings`
+	Pos   token.Position
+
+	EventPos token.Position // where the constant is declared, if it is one
+}
+
+// CallSite is a call that might be an emission, ie `s.userEvent(..., settings)`.
+type CallSite struct {
+	Pkg     string // import path of the package making the call
+	Recv    string // eg. `s`
+	Emitter string // eg. `userEvent`
+	Type    string // resolved payload type, empty if we couldn't work it out
+	Reason  string // why Type is empty, if we know, or `reasonDepth`
+	Iface   string // interface the emitter method is promoted from, if any
+	Pos     token.Position
+
+	// External is a call through an emitter method or another package's
+	// emitter, whose bindings aren't this package's to find.  The passes check
+	// those from the facts.
+	External bool
+	// EmitterPkg is the package declaring the emitter var, when we know it,
+	// which for another package's emitter isn't `Pkg`.
+	EmitterPkg string
+}
+
+// name is how we write the call's emitter, eg. `s.userEvent`.
+func (c CallSite) name() string {
+	if c.Recv == "" {
+		return c.Emitter
+	}
+	return c.Recv + "." + c.Emitter
+}
+
+// Reasons a call site's Type can be empty.
+const (
+	// reasonDynamic is an interface-typed payload whose concrete type we can't see.
+	reasonDynamic = "dynamic"
+	// reasonNoTypeInfo is a payload type info has nothing on, usually because
+	// the package didn't type check.
+	reasonNoTypeInfo = "no-type-info"
+	// reasonTypeParam is a type parameter we didn't see instantiated.
+	reasonTypeParam = "uninstantiated-type-param"
+	// reasonNoPayload is a call with no argument where `-payload-arg` says
+	// the payload goes.
+	reasonNoPayload = "no-payload-arg"
+	// reasonParam is a payload that's a parameter of the function making the
+	// call, where nothing in the package calls that function with something
+	// we can resolve.
+	reasonParam = "payload-param"
+	// reasonForwarded is a wrapper handing on an `args ...interface{}` it was
+	// given.  Its callers are what get checked, so it isn't reported.
+	reasonForwarded = "forwarded-args"
+	// reasonDepth is a call through an emitter we stopped following back at
+	// `-resolve-depth`, a local copy or its constructor, so we don't know its
+	// events.  Type is whatever it is, the events are what's missing.
+	reasonDepth = "depth"
+)
+
+// MismatchKind says why a call site ended up in the mismatch list.
+type MismatchKind string
+
+const (
+	// KindMismatch is a payload that matches none of the emitter's events.
+	KindMismatch MismatchKind = "mismatch"
+	// KindUnknown is an emitter whose events we don't have a type hint for,
+	// or that we can't find bound to any events at all.
+	KindUnknown MismatchKind = "unknown-event"
+	// KindUnresolved is a payload whose type we couldn't work out.
+	KindUnresolved MismatchKind = "unresolved"
+)
+
+// Mismatch is a call site that doesn't line up with the events its emitter is bound to.
+type Mismatch struct {
+	Kind   MismatchKind
+	Call   CallSite
+	Events []string     // every event the emitter is bound to
+	Wants  []EventConst // the bound events we have hints for
+}
+
+func (m Mismatch) String() string {
+	emitter := m.Call.name()
+	switch m.Kind {
+	case KindUnresolved:
+		if m.Call.Reason == reasonDepth {
+			return fmt.Sprintf("%s emits %s but we stopped following it back at -resolve-depth, truncated", emitter, shortType(m.Call.Type))
+		}
+		if m.Call.Reason == reasonDynamic {
+			return fmt.Sprintf("%s emits a dynamic interface value we can't resolve (bound to %s)", emitter, shortNames(m.Events))
+		}
+		if m.Call.Reason != "" {
+			return fmt.Sprintf("%s emits something we can't resolve, %s (bound to %s)", emitter, m.Call.Reason, shortNames(m.Events))
+		}
+		return fmt.Sprintf("%s emits something we can't resolve (bound to %s)", emitter, shortNames(m.Events))
+	case KindUnknown:
+		emitted := shortType(m.Call.Type)
+		if emitted == "" {
+			emitted = "something"
+		}
+		switch {
+		case len(m.Events) > 0:
+			return fmt.Sprintf("%s emits %s but %s has no type hint", emitter, emitted, shortNames(m.Events))
+		case m.Call.Iface != "":
+			return fmt.Sprintf("%s emits %s through %s but we can't find what it's bound to", emitter, emitted, shortType(m.Call.Iface))
+		}
+		return fmt.Sprintf("%s emits %s but we can't find what it's bound to", emitter, emitted)
+	}
+	emitted := shortType(m.Call.Type)
+	wants := make([]string, 0, len(m.Wants))
+	for _, w := range m.Wants {
+		// `types.Order` wanting `types.Order` needs the paths to make sense.
+		if w.HintPath != "" && shortType(w.HintPath) == emitted {
+			emitted = m.Call.Type
+			wants = append(wants, shortType(w.Name)+" wants "+w.HintPath)
+			continue
+		}
+		wants = append(wants, shortType(w.Name)+" wants "+w.Hint)
+	}
+	return fmt.Sprintf("%s emits %s but %s", emitter, emitted, strings.Join(wants, ", "))
+}
+
+// Join is the three inventories joined up: every call site with the events
+// its emitter is bound to and what their hints make of it, and the calls
+// that don't line up.  The reports built on the join all work from this so
+// they can't disagree about what a call emits.
+type Join struct {
+	Consts     map[string]EventConst // by qualified name, first declared wins
+	Calls      []JoinedCall          // every call site, in call position order
+	Mismatches []Mismatch            // the ones that don't line up, same order
+
+	bindings bindingTable
+}
+
+// JoinedCall is a call site and what the join made of it.
+type JoinedCall struct {
+	Call   CallSite
+	Events []string        // every event the emitter is bound to
+	Bound  bool            // it's an emitter call we found, see `bindingTable.events`
+	Accept map[string]bool // by event, whether its hint takes the payload, for the hinted ones
+}
+
+// Events gives the events emitter `name` in package `pkg` is bound to, in
+// binding position order.
+func (j Join) Events(pkg, name string) []string {
+	events, _ := j.bindings.events(CallSite{Pkg: pkg, Emitter: name})
+	return events
+}
+
+// JoinInventories joins the three inventories - this is the `join ET1 ET2`
+// from the old shell pipeline.  A call site's emitter has to be bound somewhere
+// in the same package and it's fine as long as its payload matches any one of
+// the emitter's bindings.  One that isn't bound anywhere is unknown, unless
+// it's `External`, which the passes check instead.  Calls through a method
+// promoted from an embedded interface are the exception: the binding lives
+// with whatever implements it so we take same-named bindings from anywhere,
+// and report the call as unknown if there aren't any.  The result is sorted by
+// call position.
+//
+// Bindings are package-scoped: if `userEvent` is bound in two files of the same
+// package they're merged, not treated as a conflict, and the emitter's events
+// are listed in source position order (file name, then line) however the
+// inventories were collected.  If two constants share a qualified name the one
+// declared first, in the same order, wins.
+//
+// The mismatches are the `Join`'s `Mismatches`, the rest of it is for the
+// reports.
+func JoinInventories(emitters []Emitter, consts []EventConst, calls []CallSite) Join {
+	return joinInventories(emitters, consts, calls, func(string) {})
+}
+
+// ComputeMismatches is `JoinInventories` for when the mismatches are all you
+// want.
+func ComputeMismatches(emitters []Emitter, consts []EventConst, calls []CallSite) []Mismatch {
+	return JoinInventories(emitters, consts, calls).Mismatches
+}
+
+// joinInventories is `JoinInventories` calling `phase` as it finishes
+// building the constant table, the emitter table and the join itself.
+func joinInventories(emitters []Emitter, consts []EventConst, calls []CallSite, phase func(string)) Join {
+	byName := constTable(consts)
+	phase("consts")
+	bindings := newBindingTable(emitters)
+	phase("emitters")
+
+	joined := Join{Consts: byName, bindings: bindings}
+	calls = append([]CallSite(nil), calls...)
+	sort.SliceStable(calls, func(i, j int) bool {
+		return lessPosition(calls[i].Pos, calls[j].Pos)
+	})
+	var out []Mismatch
+	for _, call := range calls {
+		events, ok := bindings.events(call)
+		jc := JoinedCall{Call: call, Events: events, Bound: ok, Accept: make(map[string]bool)}
+		for _, ev := range events {
+			if c, ok := byName[ev]; ok {
+				jc.Accept[ev] = c.accepts(call.Type)
+			}
+		}
+		joined.Calls = append(joined.Calls, jc)
+		switch {
+		case call.Reason == reasonForwarded:
+			continue
+		case !ok && call.Reason == reasonDepth:
+			// There's nothing to check it against but it's not nothing.
+			out = append(out, Mismatch{Kind: KindUnresolved, Call: call})
+			continue
+		case !ok && call.External:
+			continue
+		}
+		if len(events) == 0 {
+			out = append(out, Mismatch{Kind: KindUnknown, Call: call})
+			continue
+		}
+		m := Mismatch{Call: call, Events: events}
+		matched := false
+		for _, ev := range events {
+			c, ok := byName[ev]
+			if !ok || noHint(c.Hint) {
+				continue
+			}
+			if jc.Accept[ev] {
+				matched = true
+			}
+			m.Wants = append(m.Wants, c)
+		}
+		switch {
+		case call.Type == "":
+			m.Kind = KindUnresolved
+		case matched:
+			continue
+		case len(m.Wants) == 0:
+			m.Kind = KindUnknown
+		default:
+			m.Kind = KindMismatch
+		}
+		out = append(out, m)
+	}
+
+	joined.Mismatches = out
+	phase("join")
+	return joined
+}
+
+// typesMatch reports whether an emitted type satisfies a hint.  Emitted types
+// carry their full package path; how much of it has to match depends on how
+// the hint is written:
+//
+//   - a short-form hint, `rabbitmq.UserSettings`, matches on the package name
+//     alone, ie. the last element of the path, so it's satisfied by
+//     `github.com/org/rabbitmq.UserSettings` and by any other `rabbitmq`,
+//     though `EventConst.accepts` resolves it through the file's imports
+//     first where it can;
+//   - a path-qualified hint, `github.com/org/rabbitmq.UserSettings`, matches
+//     on the whole path, less `-module-prefix`, unless `-pkg-match base` says
+//     to compare last elements anyway.
+//
+// A slice satisfies a hint for its element type, so `[]types.Event` and
+// `[]*types.Event` both do for `types.Event`.
+func typesMatch(hint, emitted string) bool {
+	return matchTypes(hint, emitted, pkgsMatch)
+}
+
+// matchTypes is `typesMatch` with the package comparison left to `pkgs`.
+func matchTypes(hint, emitted string, pkgs func(hint, emitted string) bool) bool {
+	hp, hpkg, hname := splitType(hint)
+	ep, epkg, ename := splitType(emitted)
+	if hname != ename || !pkgs(hpkg, epkg) {
+		return false
+	}
+	if hp == ep {
+		return true
+	}
+	if elem := strings.TrimPrefix(ep, "[]"); elem != ep && !strings.HasPrefix(hp, "[]") {
+		return hp == strings.TrimPrefix(elem, "*")
+	}
+	return false
+}
+
+// pkgsMatch compares the package of a hint with the package of an emitted
+// type, per the rules on `typesMatch`.
+func pkgsMatch(hint, emitted string) bool {
+	if *modulePrefix != "" {
+		hint = strings.TrimPrefix(hint, *modulePrefix)
+		emitted = strings.TrimPrefix(emitted, *modulePrefix)
+	}
+	if !strings.Contains(hint, "/") || *pkgMatch == pkgMatchBase {
+		return path.Base(hint) == path.Base(emitted)
+	}
+	return hint == emitted
+}
+
+// samePkg compares whole package paths, less `-module-prefix`.
+func samePkg(hint, emitted string) bool {
+	if *modulePrefix != "" {
+		hint = strings.TrimPrefix(hint, *modulePrefix)
+		emitted = strings.TrimPrefix(emitted, *modulePrefix)
+	}
+	return hint == emitted
+}
+
+// Values for `-pkg-match`.
+const (
+	pkgMatchExact = "exact" // whole package paths, less `-module-prefix`
+	pkgMatchBase  = "base"  // the last element of the package path
+)
+
+// splitType breaks a qualified type into any `[]` and `*` on the front, the
+// package and the type name, eg. `[]*`, `github.com/org/types` and `Event`.
+// Unqualified types have an empty package.
+func splitType(t string) (mods, pkg, name string) {
+	n := len(t) - len(strings.TrimLeft(t, "[]*"))
+	mods, t = t[:n], t[n:]
+	// The package stops at the first dot after the last slash, the type
+	// name can't have one.
+	slash := strings.LastIndex(t, "/") + 1
+	dot := strings.Index(t[slash:], ".")
+	if dot < 0 {
+		return mods, "", t
+	}
+	return mods, t[:slash+dot], t[slash+dot+1:]
+}
+
+// shortType gives a type with its package cut down to the last path element,
+// which is how we show types in messages.
+func shortType(t string) string {
+	mods, pkg, name := splitType(t)
+	if pkg == "" {
+		return mods + name
+	}
+	return mods + path.Base(pkg) + "." + name
+}
+
+// constTable indexes constants by qualified name, first declared wins.
+func constTable(consts []EventConst) map[string]EventConst {
+	consts = append([]EventConst(nil), consts...)
+	sort.SliceStable(consts, func(i, j int) bool {
+		return lessPosition(consts[i].Pos, consts[j].Pos)
+	})
+	byName := make(map[string]EventConst, len(consts))
+	for _, c := range consts {
+		if _, ok := byName[c.Name]; !ok {
+			byName[c.Name] = c
+		}
+	}
+	return byName
+}
+
+// bindingTable is the emitter bindings indexed for looking call sites up,
+// both by package and emitter name and by emitter name alone.
+type bindingTable struct {
+	byPkg    map[[2]string][]string
+	anywhere map[string][]string
+}
+
+func newBindingTable(emitters []Emitter) bindingTable {
+	emitters = append([]Emitter(nil), emitters...)
+	sort.SliceStable(emitters, func(i, j int) bool {
+		return lessPosition(emitters[i].Pos, emitters[j].Pos)
+	})
+	b := bindingTable{
+		byPkg:    make(map[[2]string][]string),
+		anywhere: make(map[string][]string),
+	}
+	for _, e := range emitters {
+		k := [2]string{e.Pkg, e.Name}
+		b.byPkg[k] = addBinding(b.byPkg[k], e.Event)
+		b.anywhere[e.Name] = addBinding(b.anywhere[e.Name], e.Event)
+	}
+	return b
+}
+
+// events gives the events a call's emitter is bound to, and whether the call
+// is an emitter call at all.  A call through an embedded interface is always
+// an emitter call but may not have any events.
+func (b bindingTable) events(call CallSite) ([]string, bool) {
+	if events, ok := b.byPkg[[2]string{call.Pkg, call.Emitter}]; ok {
+		return events, true
+	}
+	if call.Iface != "" {
+		return b.anywhere[call.Emitter], true
+	}
+	return nil, false
+}
+
+// Deprecation is a call site whose emitter is bound to a deprecated event.
+type Deprecation struct {
+	Call  CallSite
+	Const EventConst
+}
+
+// DeprecatedEmissions finds every call site that can emit a deprecated event,
+// in call position order, so they can be migrated.
+func DeprecatedEmissions(joined Join) []Deprecation {
+	var out []Deprecation
+	for _, jc := range joined.Calls {
+		for _, ev := range jc.Events {
+			if c, ok := joined.Consts[ev]; ok && c.Deprecated {
+				out = append(out, Deprecation{Call: jc.Call, Const: c})
+			}
+		}
+	}
+	return out
+}
+
+// Drift is a constant whose hint disagrees with what's actually emitted for it
+// most of the time.
+type Drift struct {
+	Const   EventConst
+	Emitted string // the most common emitted type
+	Count   int    // call sites emitting it
+	Total   int    // call sites with a resolved type
+}
+
+// HintDrift finds constants where one emitted type that doesn't satisfy the
+// hint accounts for more than half of the resolved call sites.  That's usually
+// the hint going stale after a rename rather than a bunch of separate bugs.
+// Only emitters bound to just the one event count, anything else would smear
+// each call over all its events.  Only `consts` are reported on, the
+// join's other constants being someone else's.
+func HintDrift(joined Join, consts []EventConst) []Drift {
+	counts := make(map[string]map[string]int)
+	totals := make(map[string]int)
+	for _, jc := range joined.Calls {
+		if len(jc.Events) != 1 || jc.Call.Type == "" {
+			continue
+		}
+		ev := jc.Events[0]
+		if counts[ev] == nil {
+			counts[ev] = make(map[string]int)
+		}
+		counts[ev][jc.Call.Type]++
+		totals[ev]++
+	}
+	var out []Drift
+	for _, c := range consts {
+		byType := counts[c.Name]
+		if byType == nil || joined.Consts[c.Name].Pos != c.Pos || !hasValidHint(c) {
+			continue
+		}
+		d := Drift{Const: c, Total: totals[c.Name]}
+		for t, n := range byType {
+			if n > d.Count || (n == d.Count && t < d.Emitted) {
+				d.Emitted, d.Count = t, n
+			}
+		}
+		if !c.accepts(d.Emitted) && 2*d.Count > d.Total {
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Const.Pos, out[j].Const.Pos)
+	})
+	return out
+}
+
+// InferredHint is a hint for a constant without one, going by what its call
+// sites emit.
+type InferredHint struct {
+	Const EventConst
+	Hint  string // written the way a person would, eg. `types.Order`
+}
+
+// InferHints finds the constants with no hint comment whose resolved call
+// sites all emit the one type, and writes that type up as a hint.  Two types
+// is a conflict we can't settle for anyone, so those get nothing, and so does
+// a constant whose comment is there but isn't a hint, since we'd be writing
+// over someone's words.  Only `consts` get hints, like `HintDrift`.
+func InferHints(joined Join, consts []EventConst) []InferredHint {
+	emitted := make(map[string]map[string]bool)
+	for _, jc := range joined.Calls {
+		if len(jc.Events) != 1 || jc.Call.Type == "" {
+			continue
+		}
+		if emitted[jc.Events[0]] == nil {
+			emitted[jc.Events[0]] = make(map[string]bool)
+		}
+		emitted[jc.Events[0]][jc.Call.Type] = true
+	}
+	var out []InferredHint
+	for _, c := range consts {
+		if !noHint(c.Hint) || !c.end.IsValid() || len(emitted[c.Name]) != 1 {
+			continue
+		}
+		for t := range emitted[c.Name] {
+			if hint := hintFor(c, t); hintPattern.MatchString(hint) {
+				out = append(out, InferredHint{Const: c, Hint: hint})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Const.Pos, out[j].Const.Pos)
+	})
+	return out
+}
+
+// hintFor writes type `t` as a hint on `c`: short for the constant's own
+// package, which is how the hints next to it are written, and by import path
+// for anything else so it resolves without the file importing it.
+func hintFor(c EventConst, t string) string {
+	mods, pkg, name := splitType(t)
+	if pkg == c.Pkg {
+		return mods + path.Base(pkg) + "." + name
+	}
+	return t
+}
+
+// Coupling is an event constant and the packages other than its own that emit it.
+type Coupling struct {
+	Const    EventConst
+	Emitters []PkgCount // in package path order
+}
+
+// PkgCount is how many call sites in a package emit something.
+type PkgCount struct {
+	Pkg   string `json:"pkg"`
+	Calls int    `json:"calls"`
+}
+
+// CrossPackageEmissions gives, for each event constant emitted from outside
+// the package that declares it, which packages emit it and how often.  A call
+// counts for every event its emitter is bound to.  Constants come out in
+// declaration order.
+func CrossPackageEmissions(joined Join) []Coupling {
+	counts := make(map[string]map[string]int)
+	for _, jc := range joined.Calls {
+		for _, ev := range jc.Events {
+			c, ok := joined.Consts[ev]
+			if !ok || c.Pkg == jc.Call.Pkg {
+				continue
+			}
+			if counts[ev] == nil {
+				counts[ev] = make(map[string]int)
+			}
+			counts[ev][jc.Call.Pkg]++
+		}
+	}
+	var out []Coupling
+	for ev, byPkg := range counts {
+		cp := Coupling{Const: joined.Consts[ev]}
+		for pkg, n := range byPkg {
+			cp.Emitters = append(cp.Emitters, PkgCount{Pkg: pkg, Calls: n})
+		}
+		sort.Slice(cp.Emitters, func(i, j int) bool {
+			return cp.Emitters[i].Pkg < cp.Emitters[j].Pkg
+		})
+		out = append(out, cp)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Const.Pos, out[j].Const.Pos)
+	})
+	return out
+}
+
+// Conflict is one event value declared by several constants whose hints
+// disagree about what goes with it.
+type Conflict struct {
+	Value  string
+	Consts []EventConst // in position order
+}
+
+// HintConflicts finds event values declared more than once with different
+// type hints, eg. `"user.account.settings"` wanting `types.UserSettings` in
+// one package and `types.Order` in another.  Whichever constant an emitter is
+// bound to, the consumer only sees the string, so one of them is wrong.
+// Hints compare resolved to import paths where they could be, constants
+// without a hint don't disagree with anything.
+func HintConflicts(consts []EventConst) []Conflict {
+	byValue := make(map[string][]EventConst)
+	for _, c := range consts {
+		if hasValidHint(c) {
+			byValue[c.Value] = append(byValue[c.Value], c)
+		}
+	}
+	var out []Conflict
+	for v, cs := range byValue {
+		hints := make(map[string]bool)
+		for _, c := range cs {
+			hints[c.resolvedHint()] = true
+		}
+		if len(hints) < 2 {
+			continue
+		}
+		sort.SliceStable(cs, func(i, j int) bool {
+			return lessPosition(cs[i].Pos, cs[j].Pos)
+		})
+		out = append(out, Conflict{Value: v, Consts: cs})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Consts[0].Pos, out[j].Consts[0].Pos)
+	})
+	return out
+}
+
+// NamingMismatches finds constants whose name and value look like they're
+// about different things, eg. `EventUserCreated = "order.updated"`, which is
+// usually a copy and paste.  It's a heuristic: `nameOverlap` has to come out
+// below `threshold` for a constant to be reported, so 0 turns it off and 1
+// wants every word of the value in the name.
+func NamingMismatches(consts []EventConst, pattern *regexp.Regexp, threshold float64) []EventConst {
+	var out []EventConst
+	for _, c := range consts {
+		name := c.Name[strings.LastIndex(c.Name, ".")+1:]
+		// The prefix isn't part of what the event is about.
+		if loc := pattern.FindStringIndex(name); loc != nil && loc[0] == 0 {
+			name = name[loc[1]:]
+		}
+		if nameOverlap(name, c.Value) < threshold {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// nameOverlap gives the fraction of the words in an event value that turn up
+// in a camel case constant name, so `UserCreated` and `user.created` is 1 and
+// `UserCreated` and `order.updated` is 0.  Words match if one starts with the
+// other, which lets `user.create` through for `UserCreated`.  A value with no
+// words at all can't disagree with anything.
+func nameOverlap(name, value string) float64 {
+	nameWords := camelWords(name)
+	valueWords := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(valueWords) == 0 {
+		return 1
+	}
+	found := 0
+	for _, v := range valueWords {
+		for _, n := range nameWords {
+			if strings.HasPrefix(n, v) || strings.HasPrefix(v, n) {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(len(valueWords))
+}
+
+// camelWords lower cases and splits a camel case name into words, keeping
+// runs of capitals together, so `HTTPRequestFailed` is `http`, `request`,
+// `failed`.  Underscores split too.
+func camelWords(name string) []string {
+	var words []string
+	rs := []rune(name)
+	start := 0
+	for i := 1; i <= len(rs); i++ {
+		split := i == len(rs) || rs[i] == '_' ||
+			(unicode.IsUpper(rs[i]) && (!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))))
+		if !split {
+			continue
+		}
+		if w := strings.Trim(string(rs[start:i]), "_"); w != "" {
+			words = append(words, strings.ToLower(w))
+		}
+		start = i
+	}
+	return words
+}
+
+// UnusedEmitters gives the bindings of emitters that are never called, using
+// the same notion of "called" as `JoinInventories`, except that an emitter
+// called only from another package is called too.
+func UnusedEmitters(emitters []Emitter, calls []CallSite) []Emitter {
+	called := make(map[[2]string]bool)
+	viaIface := make(map[string]bool)
+	for _, call := range calls {
+		called[[2]string{call.Pkg, call.Emitter}] = true
+		if call.EmitterPkg != "" {
+			called[[2]string{call.EmitterPkg, call.Emitter}] = true
+		}
+		if call.Iface != "" {
+			viaIface[call.Emitter] = true
+		}
+	}
+	var out []Emitter
+	for _, e := range emitters {
+		if !called[[2]string{e.Pkg, e.Name}] && !viaIface[e.Name] {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// UnusedEvents gives the event constants no call site emits, in declaration
+// order.  A constant bound to an emitter nothing calls counts as unused too.
+// The inventories cover every package we loaded, so a constant emitted only
+// from another package is still used, but one emitted only from a package we
+// didn't load isn't.  It's `consts` that get checked.
+func UnusedEvents(joined Join, consts []EventConst) []EventConst {
+	emitted := make(map[string]bool)
+	for _, jc := range joined.Calls {
+		for _, ev := range jc.Events {
+			emitted[ev] = true
+		}
+	}
+	var out []EventConst
+	for _, c := range consts {
+		if !emitted[c.Name] {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// EmitterField is a struct field or package-level var of the events package's
+// `EventEmitter` type.
+type EmitterField struct {
+	Pkg  string // import path of the package declaring it
+	Name string // eg. `userEvent`
+	Var  bool   // a package-level var rather than a field
+	Pos  token.Position
+}
+
+// what says what sort of emitter it is, for messages.
+func (f EmitterField) what() string {
+	if f.Var {
+		return "emitter var"
+	}
+	return "emitter field"
+}
+
+// fieldKey identifies a field by where it's declared.  Packages we only see
+// through export data don't always have columns, so it's the line and name.
+func fieldKey(pos token.Position, name string) string {
+	return fmt.Sprintf("%s:%d:%s", pos.Filename, pos.Line, name)
+}
+
+// UnwiredEmitters gives the emitter fields that are never set, by a composite
+// literal key or an assignment, anywhere we looked.  `wired` is `fieldKey`s.
+// Calling one of these is a nil func call.
+func UnwiredEmitters(fields []EmitterField, wired []string) []EmitterField {
+	set := make(map[string]bool, len(wired))
+	for _, w := range wired {
+		set[w] = true
+	}
+	var out []EmitterField
+	for _, f := range fields {
+		if !set[fieldKey(f.Pos, f.Name)] {
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// UncalledEmitters gives the emitter fields and vars nothing calls, wired up
+// or not.  `called` is `fieldKey`s, so a call through a field promoted from an
+// embedded struct counts for the field where it's declared.  Only calls count:
+// an emitter passed around as a func value and called elsewhere gets reported.
+func UncalledEmitters(fields []EmitterField, called []string) []EmitterField {
+	set := make(map[string]bool, len(called))
+	for _, c := range called {
+		set[c] = true
+	}
+	var out []EmitterField
+	for _, f := range fields {
+		if !set[fieldKey(f.Pos, f.Name)] {
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// PrefixOutlier is an event constant named unlike the rest of its package's.
+type PrefixOutlier struct {
+	Pkg     string // import path of the declaring package
+	Name    string // qualified by import path, like `EventConst.Name`
+	Prefix  string // what of `-const-prefix` it starts with, empty for none
+	Usual   string // what most of the package's constants start with, if any
+	Emitter string // an emitter bound to it, for one we only know from that
+	Pos     token.Position
+}
+
+// InconsistentPrefixes groups each package's event constants by which of
+// `prefixes` they start with and finds the ones that don't go with the rest:
+// those in a package's smaller groups, when it mixes `Event` and `Evt` say,
+// and those bound to an emitter that start with none of them.  The latter
+// are event constants in all but name and everything keyed on the prefix,
+// the hints especially, misses them.  Declaration order.
+func InconsistentPrefixes(consts []EventConst, emitters []Emitter, prefixes []string) []PrefixOutlier {
+	byPkg := make(map[string]map[string][]PrefixOutlier)
+	seen := make(map[string]bool)
+	add := func(o PrefixOutlier) {
+		if seen[o.Name] {
+			return
+		}
+		seen[o.Name] = true
+		if byPkg[o.Pkg] == nil {
+			byPkg[o.Pkg] = make(map[string][]PrefixOutlier)
+		}
+		byPkg[o.Pkg][o.Prefix] = append(byPkg[o.Pkg][o.Prefix], o)
+	}
+	for _, c := range consts {
+		name := c.Name[strings.LastIndex(c.Name, ".")+1:]
+		add(PrefixOutlier{Pkg: c.Pkg, Name: c.Name, Prefix: longestPrefix(name, prefixes), Pos: c.Pos})
+	}
+	for _, e := range emitters {
+		// Only the bound ones we haven't got as constants, which didn't match.
+		dot := strings.LastIndex(e.Event, ".")
+		if dot < 0 || seen[e.Event] || longestPrefix(e.Event[dot+1:], prefixes) != "" {
+			continue
+		}
+		pos := e.EventPos
+		if !pos.IsValid() {
+			pos = e.Pos
+		}
+		add(PrefixOutlier{Pkg: e.Event[:dot], Name: e.Event, Emitter: e.Name, Pos: pos})
+	}
+
+	var out []PrefixOutlier
+	for _, groups := range byPkg {
+		// The usual prefix is the one most of them use, the first declared
+		// of those on a tie, and no prefix at all is never usual.
+		usual := ""
+		for prefix, g := range groups {
+			if prefix == "" {
+				continue
+			}
+			if u := groups[usual]; usual == "" || len(g) > len(u) || (len(g) == len(u) && lessPosition(g[0].Pos, u[0].Pos)) {
+				usual = prefix
+			}
+		}
+		for prefix, g := range groups {
+			if prefix == usual && usual != "" {
+				continue
+			}
+			for _, o := range g {
+				o.Usual = usual
+				out = append(out, o)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return lessPosition(out[i].Pos, out[j].Pos)
+	})
+	return out
+}
+
+// longestPrefix is the longest of `prefixes` that `name` starts with, so
+// `EventX` is an `Event` even alongside `Ev`.
+func longestPrefix(name string, prefixes []string) string {
+	longest := ""
+	for _, p := range prefixes {
+		if len(p) > len(longest) && strings.HasPrefix(name, p) {
+			longest = p
+		}
+	}
+	return longest
+}
+
+func lessPosition(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// The join's fixtures, a types package of constants and a services package
+// calling through emitters bound to them, written the way the passes would
+// record them.
+const (
+	typesPkg    = "github.com/org/types"
+	servicesPkg = "github.com/org/services"
+)
+
+func at(line int) token.Position {
+	return token.Position{Filename: "services.go", Line: line, Column: 2}
+}
+
+var (
+	settingsEvent = EventConst{Pkg: typesPkg, Name: typesPkg + ".EventPathUserAccountSettings", Hint: "types.UserSettings", HintPath: typesPkg + ".UserSettings", Pos: token.Position{Filename: "types.go", Line: 1}}
+	orderEvent    = EventConst{Pkg: typesPkg, Name: typesPkg + ".EventPathOrder", Hint: "types.Order", HintPath: typesPkg + ".Order", Pos: token.Position{Filename: "types.go", Line: 2}}
+	aliasEvent    = EventConst{Pkg: typesPkg, Name: typesPkg + ".EventPathSettingsAlias", Hint: "types.Settings", HintPath: typesPkg + ".UserSettings", Pos: token.Position{Filename: "types.go", Line: 3}}
+	auditEvent    = EventConst{Pkg: typesPkg, Name: typesPkg + ".EventPathAudit", Hint: "types.UnknownEventType", Pos: token.Position{Filename: "types.go", Line: 4}}
+)
+
+func bound(name string, c EventConst) Emitter {
+	return Emitter{Pkg: servicesPkg, Name: name, Event: c.Name, Pos: at(1)}
+}
+
+func call(line int, emitter, emitted string) CallSite {
+	c := CallSite{Pkg: servicesPkg, Recv: "s", Emitter: emitter, Pos: at(line)}
+	if emitted != "" {
+		c.Type = typesPkg + "." + emitted
+	}
+	return c
+}
+
+func TestComputeMismatches(t *testing.T) {
+	consts := []EventConst{settingsEvent, orderEvent, aliasEvent, auditEvent}
+	unresolved := call(10, "userEvent", "")
+	unresolved.Reason = reasonDynamic
+	external := call(10, "otherEvent", "Order")
+	external.External = true
+	elsewhere := call(10, "userEvent", "Order")
+	elsewhere.Pkg = "github.com/org/billing"
+	promoted := call(10, "auditEvent", "Order")
+	promoted.Pkg, promoted.Iface = "github.com/org/billing", typesPkg+".Auditor"
+	pointers := call(10, "userEvent", "")
+	pointers.Type = "[]*" + typesPkg + ".UserSettings"
+	depth := call(10, "otherEvent", "Order")
+	depth.Reason = reasonDepth
+
+	for _, tc := range []struct {
+		name     string
+		emitters []Emitter
+		calls    []CallSite
+		allow    []allowedMismatch
+		want     []string
+	}{
+		{
+			name:     "hinted match",
+			emitters: []Emitter{bound("userEvent", settingsEvent)},
+			calls:    []CallSite{call(10, "userEvent", "UserSettings")},
+		},
+		{
+			name:     "hinted mismatch",
+			emitters: []Emitter{bound("userEvent", settingsEvent)},
+			calls:    []CallSite{call(10, "userEvent", "Order")},
+			want:     []string{"mismatch: s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings"},
+		},
+		{
+			name:     "unhinted",
+			emitters: []Emitter{bound("auditEvent", auditEvent)},
+			calls:    []CallSite{call(10, "auditEvent", "Order")},
+			want:     []string{"unknown-event: s.auditEvent emits types.Order but types.EventPathAudit has no type hint"},
+		},
+		{
+			// Either binding will do, neither is a mismatch listing both.
+			name:     "multiple bindings",
+			emitters: []Emitter{bound("event", orderEvent), bound("event", settingsEvent)},
+			calls:    []CallSite{call(10, "event", "Order"), call(11, "event", "UserSettings"), call(12, "event", "Profile")},
+			want:     []string{"mismatch: s.event emits types.Profile but types.EventPathOrder wants types.Order, types.EventPathUserAccountSettings wants types.UserSettings"},
+		},
+		{
+			name:     "alias hint",
+			emitters: []Emitter{bound("aliasEvent", aliasEvent)},
+			calls:    []CallSite{call(10, "aliasEvent", "UserSettings"), call(11, "aliasEvent", "Profile")},
+			want:     []string{"mismatch: s.aliasEvent emits types.Profile but types.EventPathSettingsAlias wants types.Settings"},
+		},
+		{
+			// The passes strip the pointer, a slice of them is the slice's element.
+			name:     "pointer elements",
+			emitters: []Emitter{bound("userEvent", settingsEvent)},
+			calls:    []CallSite{pointers},
+		},
+		{
+			name:     "allowed mismatch",
+			emitters: []Emitter{bound("userEvent", settingsEvent), bound("orderEvent", orderEvent)},
+			calls:    []CallSite{call(10, "userEvent", "Order"), call(11, "orderEvent", "UserSettings")},
+			allow:    []allowedMismatch{{Emitter: "userEvent", DeclaredType: "types.UserSettings", EmittedType: "types.Order"}},
+			want:     []string{"mismatch: s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order"},
+		},
+		{
+			name:     "unresolved",
+			emitters: []Emitter{bound("userEvent", settingsEvent)},
+			calls:    []CallSite{unresolved},
+			want:     []string{"unresolved: s.userEvent emits a dynamic interface value we can't resolve (bound to types.EventPathUserAccountSettings)"},
+		},
+		{
+			name:  "unknown event",
+			calls: []CallSite{call(10, "otherEvent", "Order")},
+			want:  []string{"unknown-event: s.otherEvent emits types.Order but we can't find what it's bound to"},
+		},
+		{
+			// The passes check these from the facts.
+			name:  "external",
+			calls: []CallSite{external},
+		},
+		{
+			// Bindings are per package, the same name elsewhere is another emitter.
+			name:     "other package",
+			emitters: []Emitter{bound("userEvent", settingsEvent)},
+			calls:    []CallSite{elsewhere},
+			want:     []string{"unknown-event: s.userEvent emits types.Order but we can't find what it's bound to"},
+		},
+		{
+			// Promoted from an interface, it's bound wherever it's implemented.
+			name:     "embedded interface",
+			emitters: []Emitter{bound("auditEvent", orderEvent)},
+			calls:    []CallSite{promoted},
+		},
+		{
+			name:  "depth",
+			calls: []CallSite{depth},
+			want:  []string{"unresolved: s.otherEvent emits types.Order but we stopped following it back at -resolve-depth, truncated"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := mismatchFindings(ComputeMismatches(tc.emitters, consts, tc.calls))
+			findings = config{AllowedMismatches: tc.allow}.allowed(findings)
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Category+": "+f.Message)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestJoinCalls checks the join keeps every call, in position order, with
+// what its hints make of it, since the reports go by that rather than the
+// mismatches.
+func TestJoinCalls(t *testing.T) {
+	emitters := []Emitter{bound("event", orderEvent), bound("event", settingsEvent)}
+	calls := []CallSite{call(12, "event", "Profile"), call(10, "event", "Order"), call(11, "otherEvent", "Order")}
+	j := JoinInventories(emitters, []EventConst{orderEvent, settingsEvent}, calls)
+
+	var lines []int
+	for _, jc := range j.Calls {
+		lines = append(lines, jc.Call.Pos.Line)
+	}
+	if want := []int{10, 11, 12}; !reflect.DeepEqual(lines, want) {
+		t.Fatalf("calls on lines %v, want %v", lines, want)
+	}
+	if want := (map[string]bool{orderEvent.Name: true, settingsEvent.Name: false}); !j.Calls[0].Bound || !reflect.DeepEqual(j.Calls[0].Accept, want) {
+		t.Errorf("first call is %+v, want bound and accepting %v", j.Calls[0], want)
+	}
+	if j.Calls[1].Bound || len(j.Calls[1].Events) != 0 {
+		t.Errorf("unbound call is %+v", j.Calls[1])
+	}
+	if got, want := j.Events(servicesPkg, "event"), []string{orderEvent.Name, settingsEvent.Name}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Events gave %v, want %v", got, want)
+	}
+	if len(j.Mismatches) != 2 {
+		t.Errorf("got %d mismatches, want the unknown and the mismatch", len(j.Mismatches))
+	}
+}
+
+// TestTypesMatch compares hints with emitted types, short-form and
+// path-qualified, under each `-pkg-match` and with and without
+// `-module-prefix`.
+func TestTypesMatch(t *testing.T) {
+	prefix, match := *modulePrefix, *pkgMatch
+	t.Cleanup(func() { *modulePrefix, *pkgMatch = prefix, match })
+
+	for _, tc := range []struct {
+		hint, emitted string
+		prefix, match string
+		want          bool
+	}{
+		{"types.Order", "github.com/org/types.Order", "", pkgMatchExact, true},
+		{"types.Order", "github.com/other/types.Order", "", pkgMatchExact, true},
+		{"types.Order", "github.com/org/billing.Order", "", pkgMatchExact, false},
+		{"types.Order", "github.com/org/types.Invoice", "", pkgMatchExact, false},
+		{"github.com/org/types.Order", "github.com/org/types.Order", "", pkgMatchExact, true},
+		{"github.com/org/types.Order", "github.com/other/types.Order", "", pkgMatchExact, false},
+		{"github.com/org/types.Order", "github.com/other/types.Order", "", pkgMatchBase, true},
+		{"org/types.Order", "github.com/org/types.Order", "", pkgMatchExact, false},
+		{"org/types.Order", "github.com/org/types.Order", "github.com/", pkgMatchExact, true},
+		{"github.com/org/types.Order", "org/types.Order", "github.com/", pkgMatchExact, true},
+		{"github.com/org/types.Order", "[]*github.com/org/types.Order", "", pkgMatchExact, true},
+		{"[]github.com/org/types.Order", "github.com/org/types.Order", "", pkgMatchExact, false},
+	} {
+		*modulePrefix, *pkgMatch = tc.prefix, tc.match
+		if got := typesMatch(tc.hint, tc.emitted); got != tc.want {
+			t.Errorf("typesMatch(%q, %q) with -module-prefix %q -pkg-match %s = %v, want %v", tc.hint, tc.emitted, tc.prefix, tc.match, got, tc.want)
+		}
+	}
+}
+
+// TestInconsistentPrefixes groups by the longest prefix, so `Ev` doesn't
+// swallow the `Event`s, and a bound constant with no prefix at all is an
+// outlier even though it isn't an event constant.
+func TestInconsistentPrefixes(t *testing.T) {
+	evShipped := EventConst{Pkg: typesPkg, Name: typesPkg + ".EvShipped", Pos: token.Position{Filename: "types.go", Line: 5}}
+	topic := Emitter{Pkg: servicesPkg, Name: "topicEvent", Event: typesPkg + ".TopicRefunded", Pos: at(1)}
+	var got []string
+	for _, o := range InconsistentPrefixes([]EventConst{settingsEvent, orderEvent, evShipped}, []Emitter{topic}, []string{"Ev", "Event"}) {
+		got = append(got, shortType(o.Name)+" "+o.Prefix+"/"+o.Usual)
+	}
+	if want := []string{"types.TopicRefunded /Event", "types.EvShipped Ev/Event"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
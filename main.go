@@ -1,26 +1,196 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
 
 	"github.com/pkg/errors"
 )
 
+// Package passes run concurrently so everything they collect for the final
+// join goes through these.  `mux` guards the emitters and call sites, `muxEC`
+// the event constants.  The emitter fields and what wires them up and calls
+// them go through `mux` too.
 var mux, muxEC sync.Mutex
+var emitterInventory []Emitter
+var callInventory []CallSite
+var constInventory []EventConst
+var fieldInventory []EmitterField
+var wiredInventory []string
+var calledInventory []string
+
+// depConstInventory has the event constants of packages we only loaded as
+// dependencies, for the join to look bindings up in.  Nothing reports on them.
+var depConstInventory []EventConst
+
+var eventsPkg = flag.String("events-pkg", "", "import path of the events package, so it can be analyzed itself")
+var eventValueField = flag.String("event-value-field", "", "dotted field path holding the event string in struct-valued event vars, eg. Name")
+var resolveDepth = flag.Int("resolve-depth", 8, "how many locals to follow back to an emitter or its constructor, calls we stop short on are unresolved with reason depth")
+var modulePrefix = flag.String("module-prefix", "", "module path prefix to ignore when comparing packages, eg. github.com/org/")
+var pkgMatch = flag.String("pkg-match", pkgMatchExact, "how strictly path-qualified hints compare packages: exact or base (last path element); short hints use base unless they resolve through the file's imports")
+var mods = flag.String("mods", "", "comma separated module roots to load the packages from and join across, patterns default to ./...")
+var stdinFiles = flag.Bool("stdin-files", false, "read changed files from stdin, one per line, and only report findings in them")
+var includeVendor = flag.Bool("include-vendor", false, "also analyze packages under a vendor directory")
+var failThreshold = flag.Int("fail-threshold", 0, "exit non-zero only when there are more than this many error severity findings")
+var severity = flag.String("severity", "", "per category severity overrides, eg. mismatch=error,unresolved=warning")
+var unknownType = flag.String("unknown-type", "types.UnknownEventType", "hint given to event constants without one, empty for none")
+var optionConstructors = flag.String("option-constructors", "", "functional option functions that bind an emitter, eg. WithUserEvent=userEvent or just WithUserEvent")
+var payloadArg = flag.Int("payload-arg", -1, "which argument of an emitter call is the payload, from 0, or from the end if negative so -1 is the last")
+var constPrefix = flag.String("const-prefix", "Event", "comma separated name prefixes of the event constants, eg. Event,Evt,Topic")
+var constRegex = flag.String("const-regex", "", "regexp event constant names have to match, instead of -const-prefix")
+var reportInconsistentPrefixes = flag.Bool("report-inconsistent-prefixes", false, "report event constants that don't share their package's -const-prefix, or that are bound to an emitter without starting with one, not with -const-regex")
+var reportUnwired = flag.Bool("report-unwired", false, "report emitter fields nothing ever sets, which would be nil when called")
+var reportHintDrift = flag.Bool("report-hint-type-drift", false, "report constants whose hint disagrees with the type most of their calls emit")
+var listUnresolved = flag.Bool("list-unresolved", false, "only list the call sites whose payload type we couldn't resolve, by reason")
+var reportCrossPackage = flag.Bool("report-cross-package-emissions", false, "only list, for each event constant, the other packages that emit it")
+var reportNaming = flag.Bool("report-naming", false, "report event constants whose name and value look like they disagree")
+var detectConflicts = flag.Bool("detect-conflicts", false, "report event values declared by several constants with different type hints")
+var namingThreshold = flag.Float64("naming-threshold", 0.5, "for -report-naming, the fraction of the value's words that have to be in the name")
+var jsonRecords = flag.Bool("json", false, "only write every constant, emitter binding, call site and mismatch as one sorted JSON array")
+var dot = flag.Bool("dot", false, "only write a graphviz graph of packages, emitters, event types and call sites, for dot -Tsvg")
+var listEmitters = flag.Bool("list-emitters", false, "only list the emitter bindings and event constants with their types and positions, skipping call sites and the join, as JSON with -json")
+var showJoin = flag.Bool("show-join", false, "only print the ET1 and ET2 tables from the old pipeline and how they join")
+var strict = flag.Bool("strict", false, "fail the run on event constants without a type hint, same as -require-hints")
+var requireHints = flag.Bool("require-hints", false, "report event constants without a type hint comment as errors, not just with -report-all")
+var reportUnused = flag.Bool("report-unused", false, "report event constants that no call site emits")
+var reportUncalled = flag.Bool("report-uncalled", false, "report emitter fields and package-level vars that are never called")
+var reportAll = flag.Bool("report-all", false, "also report unused emitters and bad or dangling type hints")
+var timings = flag.Bool("timings", false, "report how long each phase took on stderr")
+var only = flag.String("only", "", "only print findings in this category, eg. unresolved")
+var outFile = flag.String("o", "", "write findings to this file instead of stdout")
+var summaryOnly = flag.Bool("summary-only", false, "only print a one line summary, OK or FAIL going by what the exit code does, findings still go to -o if it's set")
+var format = flag.String("format", "text", "how to write findings: text, json, ndjson or sarif, or markdown for a document of every emitter")
+var configFile = flag.String("config", "", "YAML or JSON file of allowed_mismatches, each an emitter, declaredType and emittedType that are meant not to line up")
+var baselineFile = flag.String("baseline", "", "don't report findings already in this baseline file, new ones still count")
+var writeBaselineFile = flag.Bool("write-baseline", false, "write this run's findings to the -baseline file and exit")
+var ignoreDirectives = flag.String("ignore-directives", "nolint:emitteranalysis,emitignore", "comments that silence findings on their own line or the line after, comma separated")
+var helpURI = flag.String("help-uri", "", "base URL of the docs for -format sarif rules, each rule links to it with #rule-id on the end")
+var color = flag.Bool("color", false, "colour text findings even when stdout isn't a terminal")
+var noColor = flag.Bool("no-color", false, "never colour text findings, same as setting NO_COLOR")
+var tui = flag.Bool("tui", false, "step through the findings interactively")
+var tuiIgnoreFile = flag.String("tui-ignore-file", "emitteranalysis-ignored.json", "the baseline -tui adds the findings marked as ignored to, for passing to -baseline")
+var dumpConfig = flag.Bool("dump-config", false, "print the effective configuration, including the constant name pattern, and exit")
+var fix = flag.Bool("fix", false, "add a type hint comment to each event constant without one whose call sites all emit the same type")
+var missingHintsCountOnly = flag.Bool("report-missing-hints-count-only", false, "only print the number of event constants without a valid type hint")
+
+var debug = flag.Bool("debug", false, "log what the passes find as they go to stderr")
+
+// trace is where the passes write what they've found as they go.  It's off
+// unless `-debug` points it at `log`, which keeps stdout for findings.
+var trace io.Writer = io.Discard
+
+// logWriter sends each write through `log`, so trace lines get the prefix and
+// go to stderr with whatever else `log` says.
+type logWriter struct{ *log.Logger }
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w.Print(string(p))
+	return len(p), nil
+}
+
 var commentStrip = regexp.MustCompile("^[ \t]*//[ \t]*")
 
+// constPattern is what an event constant's name has to match, built from
+// `-const-prefix` or `-const-regex` once the flags are parsed.  It starts out as
+// the default so the analyzer still works under drivers that never call our
+// `main`.
+var constPattern = regexp.MustCompile("^Event")
+
+// newConstPattern builds `constPattern`: `regex` as it is if there is one,
+// otherwise anything starting with one of the comma separated `prefixes`.
+func newConstPattern(prefixes, regex string) (*regexp.Regexp, error) {
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad -const-regex")
+		}
+		return re, nil
+	}
+	var quoted []string
+	for _, p := range strings.Split(prefixes, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			quoted = append(quoted, regexp.QuoteMeta(p))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil, errors.New("-const-prefix needs at least one prefix")
+	}
+	return regexp.Compile("^(?:" + strings.Join(quoted, "|") + ")")
+}
+
+// constNaming describes `constPattern` for messages, eg. `start with Event or
+// Evt`.
+func constNaming() string {
+	if *constRegex != "" {
+		return "match " + *constRegex
+	}
+	return "start with " + strings.Join(constPrefixes(), " or ")
+}
+
+// constPrefixes is `-const-prefix` split up, blanks dropped.
+func constPrefixes() []string {
+	var prefixes []string
+	for _, p := range strings.Split(*constPrefix, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
 var EmitterAnalysis = &analysis.Analyzer{
 	Name: "emitteranalysis",
 	Doc:  "reports emitter types and stuff",
 	Run:  run,
+	// The facts carry constants and bindings to whoever uses them, so a
+	// call through a field can be checked without the global join.
+	FactTypes: []analysis.Fact{new(eventFact), new(emitterFact)},
+	// What one package's pass found, for analyzers that `Require` this one.
+	ResultType: reflect.TypeOf((*PassResult)(nil)),
+	// A package that doesn't compile still has emitters and calls worth
+	// checking, whatever type checks is used and the rest is skipped.
+	RunDespiteErrors: true,
+}
+
+// PassResult is what `run` found in one package: its own share of the
+// inventories, nothing joined, and filled in for dependencies as well as
+// roots.  An emitter can be bound to several events so `Emitters` maps its
+// name to all of them, qualified the way `Emitter.Event` is.
+type PassResult struct {
+	Emitters  map[string][]string
+	Constants []EventConst
+	CallSites []CallSite
+}
+
+// roots are the packages we were asked about.  Facts mean the passes run over
+// every dependency as well, but only the roots go in the inventories.
+var roots map[*types.Package]bool
+
+// isRoot reports whether `pkg` is one of the `roots`.  Under a driver that
+// isn't our `main` nobody fills them in, and every package it hands us is one
+// it wants diagnostics for.
+func isRoot(pkg *types.Package) bool {
+	return roots == nil || roots[pkg]
 }
 
 // RUNNING THIS CLUNKMEISTER:
@@ -29,38 +199,793 @@ var EmitterAnalysis = &analysis.Analyzer{
 // cd $HOME/git/kpc
 // ./whatevs ./types/... | grep 'emitter const' | awk '{print $3,$7}' | sort -u > ET1
 // ./whatevs ./services/... | grep checkem | awk '{print $6,$4,$2}' | sort -u > ET2
-// join ET1 ET2 | awk 'NF==4 && $2!=$3 {print $4" emits "$3" but "$1" wants "$2}'
+// join ET1 ET2 | awk 'NF==4 {k=$4" emits "$3; if ($2==$3) ok[k]=1; else want[k]=want[k]" "$1" wants "$2} END {for (k in want) if (!(k in ok)) print k" but"want[k]}'
 // ```
 //
+// An emitter can be bound more than once (eg. `if` and `else` branches wiring it to
+// different events) so there's one `checkemitter` line per binding and the `awk` only
+// complains when an emitted type matches none of them.
+//
 // There's no way to run anything after `singlechecker.Main` because it calls `os.Exit`.
 // You can't get around it using `multichecker` because that intersperses the analyses.
-// My original plan was to collect all the types and the calls into maps and then join
+// The original plan was to collect all the types and the calls into maps and then join
 // them together at the end - package passes are run in arbitrary order in goroutines -
 // which means you can't guarantee seeing the type you want before the call it's used in.
-// I may well drop the `analysis` route and hack up a "create the fileset and parse"
-// but whilst we're still evaluating the worth of this POC, we can be clunky.
+//
+// So now we load the packages ourselves and hand them to `checker.Analyze`, which
+// returns once every pass is done.  The passes fill the inventories and
+// `JoinInventories` does the join in-process, which means you can run this over
+// both trees at once and skip the pipeline above:
+//
+// ```
+// ./whatevs ./types/... ./services/...
+// ```
+//
+// The lines the pipeline greps for are still there with `-debug`, on stderr.
+//
+// The passes also export facts, an `eventFact` on each event constant and an
+// `emitterFact` on each emitter field it's bound to, and check calls through
+// a field against them as they go.  That doesn't need the inventories, so the
+// same analyzer works under `multichecker` or `go vet -vettool` too, and since
+// it goes by field rather than by name it catches the odd call the join lets
+// through because a same-named emitter elsewhere in the package matches.
 
 // FLAWS:
 //
-// Doesn't catch the type of `settings` here:
+// Used to miss the type of `settings` here:
 // `err = s.userEvent(rabbitEvents.Create, md, auth.UserID, nil, settings)`
 // because it's created by a call:
 // `settings, err := s.updateUserSettings("CreateUserAccountSettings", auth.UserID, req)`
-// and we don't yet capture those return types.
+// Payload types come from type info now, which lines the results up with the
+// left hand side for us, blanks and all, so that one's fixed.  What's left is
+// interface-typed payloads we can't see into and generic helpers we never see
+// instantiated, both reported as unresolved.
 
 func main() {
-	singlechecker.Main(EmitterAnalysis)
+	// Findings go to stdout, everything operational to stderr via `log`.
+	log.SetFlags(0)
+	log.SetPrefix("emitteranalysis: ")
+
+	flag.Parse()
+	if flag.NArg() == 0 && !*dumpConfig && *mods == "" && !*stdinFiles {
+		fatal("usage: emitteranalysis package...")
+	}
+	switch *format {
+	case "text", "ndjson", "json", "sarif", "markdown":
+	default:
+		fatalf("unknown -format %q", *format)
+	}
+	if *writeBaselineFile && *baselineFile == "" {
+		fatal("-write-baseline needs -baseline")
+	}
+	if *pkgMatch != pkgMatchExact && *pkgMatch != pkgMatchBase {
+		fatalf("unknown -pkg-match %q, want %s or %s", *pkgMatch, pkgMatchExact, pkgMatchBase)
+	}
+	if *only != "" && !isCategory(*only) {
+		fatalf("unknown -only category %q, want one of %s", *only, strings.Join(categories, ", "))
+	}
+	overrides, err := parseSeverities(*severity)
+	if err != nil {
+		fatal(err)
+	}
+	var cfg config
+	if *configFile != "" {
+		if cfg, err = readConfig(*configFile); err != nil {
+			fatal(err)
+		}
+	}
+	if *strict {
+		*requireHints = true
+	}
+	// `-severity` still has the last word.
+	if _, ok := overrides[catMissingHint]; *requireHints && !ok {
+		overrides[catMissingHint] = sevError
+	}
+	if *debug {
+		trace = logWriter{log.New(os.Stderr, log.Prefix()+"debug: ", 0)}
+	}
+	if constPattern, err = newConstPattern(*constPrefix, *constRegex); err != nil {
+		fatal(err)
+	}
+	optionEmitters = parseOptionConstructors(*optionConstructors)
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "payload-arg" {
+			payloadArgSet = true
+		}
+	})
+	if *dumpConfig {
+		writeConfig(os.Stdout)
+		return
+	}
+	fmt.Fprintf(trace, "const pattern: %s\n", constPattern)
+
+	start := time.Now()
+	last := start
+	phase := func(name string) {
+		now := time.Now()
+		if *timings {
+			fmt.Fprintf(os.Stderr, "timing: %-8s %v\n", name, now.Sub(last))
+		}
+		fmt.Fprintf(trace, "timing: %-8s %v\n", name, now.Sub(last))
+		last = now
+	}
+
+	patterns := flag.Args()
+	var changed map[string]bool
+	if *stdinFiles {
+		var err error
+		if changed, err = readChanged(os.Stdin); err != nil {
+			fatal(err)
+		}
+		// With no patterns we load just what changed, otherwise the patterns
+		// give the join its context and the changed files only limit what
+		// gets reported.
+		if len(patterns) == 0 {
+			for f := range changed {
+				patterns = append(patterns, "file="+f)
+			}
+			sort.Strings(patterns)
+		}
+	}
+	pkgs, fset, err := load(patterns)
+	if err != nil {
+		fatal(err)
+	}
+	roots = make(map[*types.Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		roots[pkg.Types] = true
+	}
+	fmt.Fprintf(trace, "parse cache: %d files, %d hits\n", len(parsed.files), parsed.hits)
+	phase("load")
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		broken := false
+		for _, err := range pkg.Errors {
+			// A package that didn't load at all is ours to fail on, one
+			// that doesn't compile is just what's in the tree today.
+			if err.Kind != packages.ParseError && err.Kind != packages.TypeError {
+				reportError("%v", err)
+				continue
+			}
+			log.Print(err)
+			broken = true
+		}
+		if broken {
+			brokenPkgs = append(brokenPkgs, pkg.PkgPath)
+		}
+	})
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{EmitterAnalysis}, pkgs, nil)
+	if err != nil {
+		fatal(err)
+	}
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			reportError("%s: %v", act.Package.PkgPath, act.Err)
+		}
+	}
+	phase("analyze")
+	// The join needs the constants our emitters are bound to wherever they
+	// live, a types module we only import included.  The reports about the
+	// constants themselves stay with `constInventory`.
+	joinConsts := append(append([]EventConst(nil), constInventory...), depConstInventory...)
+	// Exits skip deferred calls so this gets called by hand on the way out.
+	total := func() {
+		if *timings {
+			fmt.Fprintf(os.Stderr, "timing: %-8s %v\n", "total", time.Since(start))
+		}
+	}
+
+	// The registry on its own, which is all a pre-commit hook wants.
+	if *listEmitters {
+		write := writeInventory
+		if *jsonRecords {
+			write = func(w io.Writer, emitters []Emitter, consts, _ []EventConst) error {
+				return writeRecords(w, emitters, consts, nil, nil, nil)
+			}
+		}
+		if err := write(os.Stdout, emitterInventory, constInventory, joinConsts); err != nil {
+			fatal(err)
+		}
+		total()
+		return
+	}
+
+	if *missingHintsCountOnly {
+		missing := 0
+		for _, c := range constInventory {
+			if !hasValidHint(c) {
+				missing++
+			}
+		}
+		fmt.Println(missing)
+		total()
+		if missing > 0 {
+			os.Exit(exitFindings)
+		}
+		return
+	}
+
+	// Everything from here on is the join one way or another.
+	joined := joinInventories(emitterInventory, joinConsts, callInventory, phase)
+
+	if *reportCrossPackage {
+		couplings := CrossPackageEmissions(joined)
+		write := writeCouplings
+		if *format != "text" {
+			write = writeCouplingsJSON
+		}
+		if err := write(os.Stdout, couplings); err != nil {
+			fatal(err)
+		}
+		total()
+		return
+	}
+
+	if *showJoin {
+		if err := writeJoin(os.Stdout, joined); err != nil {
+			fatal(err)
+		}
+		total()
+		return
+	}
+
+	if *dot {
+		if err := writeDOT(os.Stdout, emitterInventory, joined); err != nil {
+			fatal(err)
+		}
+		total()
+		return
+	}
+
+	// Markdown is documentation rather than findings.
+	if *format == "markdown" {
+		if err := writeMarkdown(os.Stdout, emitterInventory, joined); err != nil {
+			fatal(err)
+		}
+		total()
+		return
+	}
+
+	findings := mismatchFindings(joined.Mismatches)
+	findings = append(findings, deprecationFindings(DeprecatedEmissions(joined))...)
+	// The passes' fact-based mismatches always count, and so do the emitters
+	// we couldn't see behind, the hint problems are extras like the rest.
+	diagnostics := diagnosticFindings(graph, constInventory, findings)
+	always := func(f Finding) bool {
+		return f.Category == string(KindMismatch) || f.Category == catUnresolvedImpl || (*requireHints && f.Category == catMissingHint)
+	}
+	for _, f := range diagnostics {
+		if always(f) {
+			findings = append(findings, f)
+		}
+	}
+	sortFindings(findings)
+	if *reportAll || *only != "" {
+		findings = append(findings, emitterFindings(UnusedEmitters(emitterInventory, callInventory))...)
+		for _, f := range diagnostics {
+			if !always(f) {
+				findings = append(findings, f)
+			}
+		}
+		sortFindings(findings)
+	}
+	if *reportUnwired || *only == catUnwiredEmitter {
+		findings = append(findings, unwiredFindings(UnwiredEmitters(fieldInventory, wiredInventory))...)
+		sortFindings(findings)
+	}
+	if *reportUncalled || *only == catUncalledEmitter {
+		findings = append(findings, uncalledFindings(UncalledEmitters(fieldInventory, calledInventory))...)
+		sortFindings(findings)
+	}
+	if *reportHintDrift || *only == catHintDrift {
+		findings = append(findings, driftFindings(HintDrift(joined, constInventory))...)
+		sortFindings(findings)
+	}
+	if *reportNaming || *only == catNaming {
+		findings = append(findings, namingFindings(NamingMismatches(constInventory, constPattern, *namingThreshold))...)
+		sortFindings(findings)
+	}
+	if *detectConflicts || *only == catHintConflict {
+		findings = append(findings, conflictFindings(HintConflicts(constInventory))...)
+		sortFindings(findings)
+	}
+	if *reportUnused || *only == catUnusedEvent {
+		findings = append(findings, unusedEventFindings(UnusedEvents(joined, constInventory))...)
+		sortFindings(findings)
+	}
+	if *reportInconsistentPrefixes || *only == catInconsistentPrefix {
+		// A regexp doesn't say where the prefix ends, `^Evt\w+` matches the
+		// whole name, so every constant would be a prefix of its own.
+		if *constRegex != "" {
+			log.Print("-report-inconsistent-prefixes goes by -const-prefix, skipping it with -const-regex")
+		} else {
+			findings = append(findings, prefixFindings(InconsistentPrefixes(constInventory, emitterInventory, constPrefixes()))...)
+			sortFindings(findings)
+		}
+	}
+	findings = dedupeFindings(findings)
+	findings = unignored(findings)
+	findings = cfg.allowed(findings)
+	inferred := InferHints(joined, constInventory)
+	suggestHints(findings, inferred)
+	if fixes := append(hintFixes(inferred), diagnosticFixes(graph)...); *fix && len(fixes) > 0 {
+		n, err := applyFixes(fset, fixes)
+		if err != nil {
+			fatal(err)
+		}
+		log.Printf("added %d type hints", n)
+	}
+	setSeverities(findings, overrides)
+	runFinalizers(Result{
+		Findings: findings,
+		Emitters: emitterInventory,
+		Consts:   constInventory,
+		Calls:    callInventory,
+	})
+	// The baseline is of the whole run, before `-stdin-files` narrows it.
+	if *writeBaselineFile {
+		if err := writeFile(*baselineFile, writeBaseline, findings); err != nil {
+			fatal(err)
+		}
+		log.Printf("wrote %d findings to baseline %s", len(findings), *baselineFile)
+		total()
+		return
+	}
+	if *baselineFile != "" {
+		b, err := readBaseline(*baselineFile)
+		if err != nil {
+			fatal(err)
+		}
+		var dropped int
+		findings, dropped = b.suppress(findings)
+		log.Printf("%d findings suppressed by baseline %s", dropped, *baselineFile)
+	}
+	if changed != nil {
+		findings = inFiles(findings, changed)
+	}
+	// The whole inventory, for dashboards rather than people.  The mismatch
+	// records are what's left of the findings, so the baseline, ignores and
+	// severities say what fails the run the same as they do for any format.
+	if *jsonRecords {
+		if err := writeRecords(os.Stdout, emitterInventory, constInventory, callInventory, joined.Mismatches, findings); err != nil {
+			fatal(err)
+		}
+	} else if *tui {
+		// With `-fix` they've all been applied already.
+		var fixes []*analysis.SuggestedFix
+		if !*fix {
+			fixes = findingFixes(findings, inferred)
+		}
+		if err := review(findings, fixes, fset, *tuiIgnoreFile); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	// `-only` is about what gets printed, mismatches elsewhere still count.
+	shown := findings
+	if *only != "" {
+		shown = onlyCategory(findings, *only)
+	}
+	write := writeText
+	if *listUnresolved {
+		shown = onlyCategory(findings, string(KindUnresolved))
+		sortByReason(shown)
+		write = writeReasons
+	}
+	switch *format {
+	case "ndjson":
+		write = writeNDJSON
+	case "json":
+		write = func(w io.Writer, findings []Finding) error {
+			return writeJSON(w, findings, runErrors)
+		}
+	case "sarif":
+		write = func(w io.Writer, findings []Finding) error {
+			return writeSARIF(w, findings, *helpURI)
+		}
+	}
+	switch {
+	case *jsonRecords:
+	case *outFile != "":
+		if err := writeFile(*outFile, write, shown); err != nil {
+			fatal(err)
+		}
+	case !*summaryOnly:
+		if *format == "text" && !*listUnresolved && useColor(os.Stdout) {
+			write = writeColor
+		}
+		if err := write(os.Stdout, shown); err != nil {
+			fatal(err)
+		}
+	}
+
+	mismatches, unresolved, failing := 0, 0, 0
+	for _, f := range findings {
+		switch f.Category {
+		case string(KindMismatch):
+			mismatches++
+		case string(KindUnresolved):
+			unresolved++
+		}
+		if f.Severity == sevError {
+			failing++
+		}
+	}
+
+	// Packages that didn't load or passes that failed mean the findings
+	// can't be trusted either way.  Otherwise it's the error severity
+	// findings that fail the run, unknown and unresolved calls are worth
+	// seeing but they're not something we can tell anyone they've got wrong.
+	code := 0
+	switch {
+	case len(runErrors) > 0:
+		code = exitError
+	case failing > *failThreshold:
+		code = exitFindings
+	}
+	if *summaryOnly && !*jsonRecords {
+		verdict := "OK"
+		switch code {
+		case exitFindings:
+			verdict = "FAIL"
+		case exitError:
+			verdict = "ERROR"
+		}
+		if mismatches == 0 && unresolved == 0 && failing == 0 {
+			fmt.Println(verdict)
+		} else {
+			fmt.Printf("%s: %d mismatches, %d unresolved, %d errors (fail threshold %d)\n", verdict, mismatches, unresolved, failing, *failThreshold)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "%d constants, %d emitters, %d call sites, %d mismatches, %d errors (fail threshold %d)\n",
+			len(constInventory), countEmitters(emitterInventory), len(callInventory), mismatches, failing, *failThreshold)
+	}
+	if len(brokenPkgs) > 0 {
+		sort.Strings(brokenPkgs)
+		log.Printf("warning: %d packages with errors, analyzed as far as they type check: %s", len(brokenPkgs), strings.Join(brokenPkgs, ", "))
+	}
+	total()
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// countEmitters gives how many emitters there are, as opposed to bindings,
+// going by package and name.
+func countEmitters(emitters []Emitter) int {
+	seen := make(map[string]bool)
+	for _, e := range emitters {
+		seen[e.Pkg+"."+e.Name] = true
+	}
+	return len(seen)
+}
+
+// writeConfig prints every flag's effective value, one `name = value` per line,
+// then the constant pattern they add up to.
+func writeConfig(w io.Writer) {
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(w, "%s = %q\n", f.Name, f.Value.String())
+	})
+	fmt.Fprintf(w, "const pattern = %q\n", constPattern.String())
+}
+
+// useColor reports whether text findings written to `f` should be coloured:
+// `-no-color` and `NO_COLOR` say no, `-color` says yes, and otherwise it's
+// whether `f` is a terminal.
+func useColor(f *os.File) bool {
+	if *noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if *color {
+		return true
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Result is everything a run ends up with, for finalizers.
+type Result struct {
+	Findings []Finding // every finding, before `-only` or anything else filters them
+	Emitters []Emitter
+	Consts   []EventConst
+	Calls    []CallSite
+}
+
+// finalizers are called with the `Result` once the join is done.
+var finalizers []func(Result)
+
+// RegisterFinalizer adds a function to call once with the complete `Result`,
+// after the join and before any findings are written, so whatever's built
+// around this can do its own thing with the inventories - stuff them in a
+// database, say.  Register from an `init` in your own file in this package.
+// Finalizers run in registration order on the main goroutine and share the
+// slices with each other and the output, so they mustn't modify them.
+// They're not called for the count-only or cross-package views.
+func RegisterFinalizer(f func(Result)) {
+	finalizers = append(finalizers, f)
+}
+
+func runFinalizers(r Result) {
+	for _, f := range finalizers {
+		f(r)
+	}
+}
+
+// runErrors are the operational problems we hit along the way, as opposed to
+// findings.  They're only touched from `main`.
+var runErrors []string
+
+// brokenPkgs are the packages that loaded but didn't parse or type check.
+// They get analyzed as far as they go, so they're a warning at the end rather
+// than something that fails the run.
+var brokenPkgs []string
+
+// Exit codes: findings are 1 so CI can tell them from the tool itself
+// falling over, which is 2.
+const (
+	exitFindings = 1
+	exitError    = 2
+)
+
+// fatal is `log.Fatal` with our exit code for when we can't carry on.
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	os.Exit(exitError)
+}
+
+// fatalf is `fatal` with formatting.
+func fatalf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(exitError)
+}
+
+// reportError logs an operational error to stderr and remembers it for
+// output formats that carry errors alongside findings.
+func reportError(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	runErrors = append(runErrors, msg)
+	log.Print(msg)
+}
+
+// writeFile writes findings to a file with one of the `write*` functions.
+func writeFile(path string, write func(io.Writer, []Finding) error, findings []Finding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, findings); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readChanged reads file names one per line, skipping blanks, and gives them
+// back as absolute paths so they compare with finding positions.
+func readChanged(r io.Reader) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		abs, err := filepath.Abs(line)
+		if err != nil {
+			return nil, err
+		}
+		changed[abs] = true
+	}
+	return changed, sc.Err()
+}
+
+// load loads the packages matching `patterns` with everything `checker.Analyze`
+// needs and drops vendored ones unless we've been asked to include them.  With
+// `-mods` the patterns are loaded in each module root in turn and the results
+// merged, so the passes fill one set of inventories and the join sees
+// constants from one repo and emitters from another.  The file set comes back
+// on its own since there may be no packages left to get it from.
+func load(patterns []string) ([]*packages.Package, *token.FileSet, error) {
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedTypes |
+		packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule |
+		packages.NeedDeps
+	dirs := []string{""}
+	if *mods != "" {
+		dirs = strings.Split(*mods, ",")
+		if len(patterns) == 0 {
+			patterns = []string{"./..."}
+		}
+	}
+	fset := token.NewFileSet()
+	seen := make(map[string]bool)
+	var pkgs []*packages.Package
+	for _, dir := range dirs {
+		loaded, err := packages.Load(&packages.Config{Mode: mode, Dir: dir, Fset: fset, ParseFile: parsed.parse}, patterns...)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "loading %s", dir)
+		}
+		// A module that's a dependency of another can turn up twice.
+		for _, pkg := range loaded {
+			if !seen[pkg.ID] {
+				seen[pkg.ID] = true
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+	if *includeVendor {
+		return pkgs, fset, nil
+	}
+	var keep []*packages.Package
+	for _, pkg := range pkgs {
+		if !isVendored(pkg) {
+			keep = append(keep, pkg)
+		}
+	}
+	return keep, fset, nil
+}
+
+// parseCache keeps the files `load` has parsed so each `-mods` root doesn't
+// parse every dependency they share all over again.  `packages.Load` parses
+// concurrently, hence the lock; two goroutines racing on the same file just
+// means one of them wasted its time.
+type parseCache struct {
+	sync.Mutex
+	files map[string]*ast.File
+	hits  int
+}
+
+var parsed = &parseCache{files: make(map[string]*ast.File)}
+
+// parse is a `packages.Config.ParseFile`.  Nothing goes by `ast.Object` so
+// it skips resolving them.
+func (c *parseCache) parse(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	c.Lock()
+	f, ok := c.files[filename]
+	if ok {
+		c.hits++
+	}
+	c.Unlock()
+	if ok {
+		return f, nil
+	}
+	f, err := parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		// Not kept, so each load that needs it says what's wrong with it.
+		return f, err
+	}
+	c.Lock()
+	c.files[filename] = f
+	c.Unlock()
+	return f, nil
+}
+
+// isVendored reports whether a package lives under a `vendor` directory.  In
+// GOPATH mode that shows up in the import path, in module mode only in the files.
+func isVendored(pkg *packages.Package) bool {
+	if strings.Contains(pkg.PkgPath, "/vendor/") || strings.HasPrefix(pkg.PkgPath, "vendor/") {
+		return true
+	}
+	for _, f := range pkg.GoFiles {
+		if strings.Contains(filepath.ToSlash(f), "/vendor/") {
+			return true
+		}
+	}
+	return false
 }
 
-func run(pass *analysis.Pass) (interface{}, error) {
-	// fmt.Printf("==> PASS ==> %v\n", pass)
+func run(pass *analysis.Pass) (result interface{}, err error) {
+	// Half-checked code has turned up nil types we didn't expect before.
+	// That's one package's findings lost, not the run.
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("analyzing %s: %v", pass.Pkg.Path(), r)
+		}
+	}()
+	// A dependency only gets looked at for its facts, quietly.
+	root := isRoot(pass.Pkg)
+	tr := trace
+	if !root {
+		tr = io.Discard
+	}
+	fmt.Fprintf(tr, "==> PASS ==> %s\n", pass.Pkg.Path())
+	// Calls get checked against their field's facts once every binding in
+	// the package has been seen.
+	var calls []factCall
+	res := &PassResult{Emitters: make(map[string][]string)}
+	// Emitters whose constructor we stopped following at `-resolve-depth`,
+	// so calls through them can say why they've no events.
+	truncated := make(map[string]bool)
+	// This package's bindings, and its missing hints, which wait for them and
+	// the call sites so they can come with a fix.
+	var bound []Emitter
+	var missing []analysis.Diagnostic
+
+	// Lines silenced by a directive, for this package's own diagnostics.
+	ignore := make(map[lineKey]bool)
+	for _, file := range pass.Files {
+		for k := range directiveLines(pass, file) {
+			ignore[k] = true
+		}
+	}
+	if root {
+		mux.Lock()
+		for k := range ignore {
+			ignoredLines[k] = true
+		}
+		mux.Unlock()
+	}
+
+	// Emitter methods, `func (s *svc) userEvent(evt rabbitEvents.EventType, ...) error`,
+	// which only the facts know about.  They come first so a call through an
+	// interface finds the `Emit` behind it wherever in the package that is.
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				emitterMethod(pass, fd)
+			}
+		}
+	}
 
+	impls := implementations(pass)
+	params := parameters(pass)
+	// Calls to this package's functions and the emitter calls whose payload
+	// is one of their parameters, for lining the two up at the end.
+	callsTo := make(map[*types.Func][]*ast.CallExpr)
+	var viaParams []paramCall
 	for _, file := range pass.Files {
-		emitters := make(map[string]string)
+		// An emitter can be bound to several events (conditional wiring) so
+		// we keep every binding we see rather than the last one.
+		emitters := make(map[string][]string)
+
+		// bind records emitter `name` as bound to the constant in `v` if `v`
+		// is a call to an events constructor, `at` being where it happens.
+		bind := func(name string, field *types.Var, v *ast.CallExpr, at token.Pos) {
+			fi, fse, err := eventsSelectorParts(pass, v.Fun)
+			// The constructor has to come from the events package, by
+			// import path rather than whatever it's called in this file,
+			// and a local, `bus.Emit(...)`, has to have come from there too.
+			if err == nil {
+				var ok bool
+				if ok, err = fromEventsPkg(pass, v.Fun, 0); errors.Is(err, errDepth) {
+					truncated[name] = true
+				} else if !ok {
+					err = errNotConstructor
+				}
+			}
+			if err != nil || (fi == pass.Pkg.Name() && fse != "Emit") || len(v.Args) == 0 {
+				return
+			}
+			ai, ase, err := eventsSelectorParts(pass, v.Args[0])
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(tr, "KVE: %s %s.%s %s.%s\n", name, fi, fse, ai, ase)
+			fmt.Fprintf(tr, "emitter: (%s) => (%s.%s)\n", name, ai, ase)
+			// Remember the mapping of emitter name to emission type.
+			emitters[name] = addBinding(emitters[name], ai+"."+ase)
+			bindFact(pass, field, v.Args[0])
+			e := Emitter{
+				Pkg:   pass.Pkg.Path(),
+				Name:  name,
+				Event: eventName(pass, v.Args[0], ai+"."+ase),
+				Pos:   pass.Fset.Position(at),
+			}
+			if c := constObj(pass, v.Args[0]); c != nil {
+				e.EventPos = pass.Fset.Position(c.Pos())
+			}
+			res.Emitters[name] = addBinding(res.Emitters[name], e.Event)
+			bound = append(bound, e)
+			if !root {
+				return
+			}
+			mux.Lock()
+			emitterInventory = append(emitterInventory, e)
+			mux.Unlock()
+		}
 
 		ast.Inspect(file, func(n ast.Node) bool {
-			// Uncomment this for when you can't figure out wth something is going to be.
-			// fmt.Printf("%T %v\n", n, n)
+			// For when you can't figure out wth something is going to be.
+			fmt.Fprintf(tr, "%T %v\n", n, n)
 
 			// Our emitter functions are defined thusly:
 			// `userEvent:   rabbitEvents.Emit(types.EventPathUserAccountSettings)`
@@ -68,20 +993,58 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			// `rabbitEvents.Emit`, the argument is our constant.
 			if kve, ok := n.(*ast.KeyValueExpr); ok {
 				if i, ok := kve.Key.(*ast.Ident); ok {
+					// Whatever it's set to, the field isn't nil any more.
+					wired(pass, i)
 					if v, ok := kve.Value.(*ast.CallExpr); ok {
-						fi, fse, err := selectorParts(v.Fun)
-						if err == nil {
-							if len(v.Args) > 0 {
-								ai, ase, err := selectorParts(v.Args[0])
-								if err == nil {
-									fmt.Printf("KVE: %s %s.%s %s.%s\n", i.Name, fi, fse, ai, ase)
-									fmt.Printf("emitter: (%s) => (%s.%s)\n", i.Name, ai, ase)
-									// Remember the mapping of emitter name to emission type.
-									emitters[i.Name] = ai + "." + ase
-								}
-							}
+						bind(i.Name, emitterVar(pass, i), v, kve.Pos())
+					}
+				}
+			}
+
+			// Emitters can be package-level vars too, grouped or not:
+			// `var userEvent = rabbitEvents.Emit(types.EventPathUserAccountSettings)`
+			// Locals can't be called from anywhere we'd see, so they don't count.
+			if g, ok := n.(*ast.GenDecl); ok && g.Tok == token.VAR {
+				for _, x := range g.Specs {
+					q, ok := x.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+					for k, name := range q.Names {
+						v := emitterVar(pass, name)
+						if v == nil {
+							continue
+						}
+						if root && isEmitterType(v.Type()) {
+							mux.Lock()
+							fieldInventory = append(fieldInventory, EmitterField{
+								Pkg:  pass.Pkg.Path(),
+								Name: name.Name,
+								Var:  true,
+								Pos:  pass.Fset.Position(name.Pos()),
+							})
+							mux.Unlock()
+						}
+						if len(q.Values) != len(q.Names) {
+							continue
 						}
+						wired(pass, name)
+						if ce, ok := q.Values[k].(*ast.CallExpr); ok {
+							bind(name.Name, v, ce, name.Pos())
+						}
+					}
+				}
+			}
 
+			// With functional options the constructor is an argument to the
+			// option, `WithUserEvent(rabbitEvents.Emit(types.EventX))`, and
+			// `-option-constructors` says which emitter that is.
+			if ce, ok := n.(*ast.CallExpr); ok {
+				if name, ok := optionEmitter(ce.Fun); ok {
+					for _, arg := range ce.Args {
+						if v, ok := arg.(*ast.CallExpr); ok {
+							bind(name, nil, v, ce.Pos())
+						}
 					}
 				}
 			}
@@ -90,38 +1053,196 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			// calls where the method matches one of our known emitters.
 			// ie `err = s.userEvent(rabbitEvents.Create, md, auth.UserID, nil, settings)`
 			// since we've already seen `userEvent` being typed as `EventEmitter`, this is us.
-			if ce, ok := n.(*ast.CallExpr); ok {
+			// `-list-emitters` doesn't care who calls what.
+			if ce, ok := n.(*ast.CallExpr); ok && !*listEmitters {
+				if fn := typeutil.StaticCallee(pass.TypesInfo, ce); fn != nil && fn.Pkg() == pass.Pkg {
+					callsTo[fn] = append(callsTo[fn], ce)
+				}
+				// Any call through an emitter field or var means it's used,
+				// bound or not.
+				if v := calledVar(pass, ce.Fun); v != nil && root && isEmitterType(v.Type()) {
+					mux.Lock()
+					calledInventory = append(calledInventory, fieldKey(pass.Fset.Position(v.Pos()), v.Name()))
+					mux.Unlock()
+				}
+				// Through an interface we can only check the call if we can
+				// tell what's behind it, and it's worth saying when we can't.
+				if v := ifaceEmitterField(pass, ce.Fun); v != nil && root && implEmitter(pass, impls[v]) == nil {
+					if pos := pass.Fset.Position(ce.Lparen); !ignoredAt(ignore, pos) {
+						pass.Report(analysis.Diagnostic{
+							Pos:      ce.Lparen,
+							Category: catUnresolvedImpl,
+							Message:  fmt.Sprintf("could not resolve emitter implementation behind %s, %d concrete types seen", types.ExprString(ce.Fun.(*ast.SelectorExpr).X), len(impls[v])),
+						})
+					}
+				}
 				fi, fse, err := selectorParts(ce.Fun)
-				if err == nil {
-					// fmt.Printf("CALL %s.%s\n", fi, fse)
+				switch {
+				case errors.Is(err, errNotIdent):
+					// `s.WithCtx(ctx).userEvent(...)` has a chain for a receiver.
+					fi, fse, err = chainedEmitter(pass, ce.Fun)
+				case errors.Is(err, errNotSelector):
+					// `userEvent(...)` is a package-level emitter, or a local
+					// copy of one, or of a field, going by its type.
+					fi, fse, err = packageEmitter(pass, ce.Fun)
+					if errors.Is(err, errNotEmitter) {
+						fi, fse, err = localEmitter(pass, ce.Fun)
+					}
+				}
+				// Plenty of calls look like `s.userEvent(...)`, only the ones
+				// through an emitter are call sites.  A local we gave up
+				// following back is one too, we just can't say whose.
+				truncatedLocal := errors.Is(err, errDepth)
+				if (err == nil && isEmitterCall(pass, ce.Fun)) || truncatedLocal {
+					fmt.Fprintf(tr, "CALL %s.%s\n", fi, fse)
 					if len(ce.Args) > 0 {
-						// fmt.Printf("LAST ARG %s.%s: %T\n", fi, fse, ce.Args[len(ce.Args)-1])
-						if ai, ok := ce.Args[len(ce.Args)-1].(*ast.Ident); ok {
-							if ai.Obj == nil {
-								fmt.Printf("%s.%s OBJ is nil for some reason.", fi, fse)
+						fmt.Fprintf(tr, "LAST ARG %s.%s: %T\n", fi, fse, ce.Args[len(ce.Args)-1])
+						// Which events it's bound to gets decided in the join
+						// since it could be bound in another file.
+						recorded := false
+						recordAt := func(t, reason string, at token.Pos) {
+							recorded = true
+							if t == "" && reason == "" {
+								reason = reasonNoTypeInfo
+							}
+							if truncatedLocal {
+								reason = reasonDepth
+							}
+							call := CallSite{
+								Pkg:     pass.Pkg.Path(),
+								Recv:    fi,
+								Emitter: fse,
+								Type:    t,
+								Reason:  reason,
+								Iface:   promotedFrom(pass, ce.Fun),
+								Pos:     pass.Fset.Position(at),
+							}
+							v := calledVar(pass, ce.Fun)
+							call.External = v == nil || v.Pkg() != pass.Pkg
+							if v != nil && v.Pkg() != nil {
+								call.EmitterPkg = v.Pkg().Path()
 							}
-							// fmt.Printf("%s -> %p\n", fse, ai.Obj)
-							t, err := typeFromObj(ai.Obj, fse)
-							if err == nil {
-								if v, ok := emitters[fse]; ok {
-									fmt.Printf("checkemitter: %s.%s => %s => %s L= %d\n", fi, fse, t, v, pass.Fset.Position(ce.Lparen).Line)
+							if v != nil {
+								calls = append(calls, factCall{Obj: v, Call: call, Pos: at})
+							} else if obj := implEmitter(pass, impls[ifaceEmitterField(pass, ce.Fun)]); obj != nil {
+								calls = append(calls, factCall{Obj: obj, Call: call, Pos: at})
+							} else if fn := calledMethod(pass, ce.Fun); fn != nil {
+								calls = append(calls, factCall{Obj: fn, Call: call, Pos: at})
+							}
+							res.CallSites = append(res.CallSites, call)
+						}
+						record := func(t, reason string) { recordAt(t, reason, ce.Lparen) }
+						// `any(settings)` tells us nothing, `settings` might.
+						arg, ok := callPayload(pass, ce)
+						var t types.Type
+						if ok {
+							arg = unwrapAny(pass, arg)
+							// Type info has already done the hard work: pointers,
+							// aliases, call results, `s.repo.Get(id).Settings` and
+							// `[]types.Event{a, b}`, which the join compares by
+							// element type.
+							t = pass.TypesInfo.TypeOf(arg)
+						}
+						// A wrapper handing on its `args...` still uses the
+						// emitter, it just hasn't a payload of its own.
+						if forwardsArgs(pass, ce) {
+							record("", reasonForwarded)
+						} else {
+							switch tt := t.(type) {
+							case nil:
+								if !ok {
+									record("", reasonNoPayload)
+									break
+								}
+								record("", reasonNoTypeInfo)
+							case *types.TypeParam:
+								// Inside a generic helper `item` is a `T` and what it
+								// really is depends on who called the helper, so that's
+								// a call site per instantiation.
+								for _, it := range instantiations(pass, tt) {
+									record(typeName(it), "")
+								}
+								if !recorded {
+									record("", reasonTypeParam)
 								}
+							default:
+								name := typeName(t)
+								// An interface-typed payload only has a useful type if
+								// we can see what was put into it.
+								if types.IsInterface(t) {
+									name = ""
+									ai, isIdent := arg.(*ast.Ident)
+									if isIdent {
+										name, _ = dynamicType(pass, ai)
+									}
+									// A wrapper's parameter, `notify(evt, payload any)`,
+									// is whatever the wrapper's callers pass, so that
+									// waits until we've seen them.
+									if p, isParam := params[pass.TypesInfo.Uses[ai]]; isIdent && isParam && name == "" {
+										viaParams = append(viaParams, paramCall{param: p, at: ce.Lparen, record: recordAt})
+										break
+									}
+									if name == "" {
+										record("", reasonDynamic)
+										break
+									}
+								}
+								for _, v := range emitters[fse] {
+									fmt.Fprintf(tr, "checkemitter: %s.%s => %s => %s L= %d\n", fi, fse, name, v, pass.Fset.Position(ce.Lparen).Line)
+								}
+								record(name, "")
 							}
 						}
 					}
 				}
 			}
 
+			// `s.userEvent = rabbitEvents.Emit(...)` wires a field up as well,
+			// and `userEvent = ...` in an `init` a package-level var.
+			if as, ok := n.(*ast.AssignStmt); ok {
+				for k, lhs := range as.Lhs {
+					var name *ast.Ident
+					switch x := lhs.(type) {
+					case *ast.SelectorExpr:
+						name = x.Sel
+					case *ast.Ident:
+						name = x
+					default:
+						continue
+					}
+					wired(pass, name)
+					// If it's a constructor call, that's a binding just like
+					// the struct literal's, as long as it's a field or
+					// package-level var and not some local.
+					if len(as.Lhs) != len(as.Rhs) {
+						continue
+					}
+					if v := emitterVar(pass, lhs); v != nil {
+						if ce, ok := as.Rhs[k].(*ast.CallExpr); ok {
+							bind(name.Name, v, ce, lhs.Pos())
+						}
+					}
+				}
+			}
+
 			// If we have a struct field of type `rabbitEvents.EventEmitter`, that's
-			// going to be the name of our emitter later.  Although we don't currently
-			// do anything with this information right now...
-			if f, ok := n.(*ast.Field); ok {
+			// going to be the name of our emitter later.  We keep them so we can
+			// tell which ones never get wired up and would be nil when called.
+			if f, ok := n.(*ast.Field); ok && root {
 				if len(f.Names) > 0 {
-					fmt.Printf("FIELD N=%s T=%s t=%T\n", f.Names[0].Name, f.Type, f.Type)
-					i, se, err := selectorParts(f.Type)
-					if err == nil {
-						if i == "rabbitEvents" && se == "EventEmitter" {
-							fmt.Printf("Found an emitter: %s\n", f.Names[0].Name)
+					fmt.Fprintf(tr, "FIELD N=%s T=%s t=%T\n", f.Names[0].Name, f.Type, f.Type)
+					if isEmitterType(pass.TypesInfo.TypeOf(f.Type)) {
+						fmt.Fprintf(tr, "Found an emitter: %s\n", f.Names[0].Name)
+						for _, name := range f.Names {
+							if v, ok := pass.TypesInfo.Defs[name].(*types.Var); ok && v.IsField() {
+								mux.Lock()
+								fieldInventory = append(fieldInventory, EmitterField{
+									Pkg:  pass.Pkg.Path(),
+									Name: name.Name,
+									Pos:  pass.Fset.Position(name.Pos()),
+								})
+								mux.Unlock()
+							}
 						}
 					}
 				}
@@ -129,22 +1250,106 @@ func run(pass *analysis.Pass) (interface{}, error) {
 
 			// For our constants, we're looking for lines matching this kind of pattern.
 			// `const XYZ = "blah.blah" // pkg.type`
+			// Some event systems wrap the string instead, which can't be a `const`:
+			// `var EventXYZ = EventType{Name: "blah.blah"} // pkg.type`
 			if g, ok := n.(*ast.GenDecl); ok {
-				if g.Tok == token.CONST {
-					// fmt.Printf("const: pos=%d\n", g.TokPos)
+				if g.Tok == token.CONST || (g.Tok == token.VAR && *eventValueField != "") {
+					fmt.Fprintf(tr, "const: pos=%d\n", g.TokPos)
+					var last []ast.Expr
 					for _, x := range g.Specs {
 						if q, ok := x.(*ast.ValueSpec); ok {
-							if q.Values != nil {
-								// If the first value is a `BasicLit`, it might be our string.
-								if b, ok := q.Values[0].(*ast.BasicLit); ok {
-									hint := "types.UnknownEventType"
+							// In a `const (...)` block a spec with no values
+							// repeats the last one's, `iota` and all.
+							values := q.Values
+							if values == nil && g.Tok == token.CONST {
+								values = last
+							}
+							last = values
+							if values != nil {
+								// If the first value is constant, a literal or
+								// `prefix + ".foo"` or another constant, it might be
+								// our string.  The constant itself has the value
+								// for this spec, a repeated `iota` included.
+								value, ok := "", false
+								if c, isConst := pass.TypesInfo.Defs[q.Names[0]].(*types.Const); isConst {
+									value, ok = constString(c.Val()), true
+								}
+								if g.Tok == token.VAR {
+									value, ok = "", false
+									if cl, isLit := values[0].(*ast.CompositeLit); isLit {
+										if v, found := fieldValue(cl, strings.Split(*eventValueField, ".")); found {
+											value, ok = constValue(pass, v)
+										}
+									}
+								}
+								if ok {
+									// No comment and no doc is fine, the constant still
+									// gets recorded with the sentinel so the hint
+									// reports can find it.
+									hint, deprecated := *unknownType, false
 									// The comment is the type hint we're ultimately after.
 									if q.Comment != nil {
-										hint = commentStrip.ReplaceAllString(q.Comment.List[0].Text, "")
+										hint, deprecated = parseHint(commentStrip.ReplaceAllString(q.Comment.List[0].Text, ""))
+										if hint == "" {
+											hint = *unknownType
+										}
+									}
+									// The usual Go `Deprecated:` paragraph counts too.  A lone
+									// `const` has its doc comment on the `GenDecl`.
+									doc := q.Doc
+									if doc == nil && !g.Lparen.IsValid() {
+										doc = g.Doc
+									}
+									if doc != nil && strings.Contains(doc.Text(), "Deprecated:") {
+										deprecated = true
 									}
-									// We only want constants beginning with `Event`.
-									if strings.HasPrefix(q.Names[0].Name, "Event") {
-										fmt.Printf("emitter const= %s.%s event= %s type= %s\n", pass.Pkg.Name(), q.Names[0].Name, b.Value, hint)
+									// We only want constants matching `constPattern`, ie.
+									// beginning with one of `-const-prefix` or matching `-const-regex`.
+									if constPattern.MatchString(q.Names[0].Name) {
+										fmt.Fprintf(tr, "emitter const= %s.%s event= %q type= %s\n", pass.Pkg.Path(), q.Names[0].Name, value, hint)
+										c := EventConst{
+											Pkg:        pass.Pkg.Path(),
+											Name:       pass.Pkg.Path() + "." + q.Names[0].Name,
+											Value:      value,
+											Hint:       hint,
+											HintPath:   resolveHint(pass, file, hint),
+											Dangling:   hintPattern.MatchString(hint) && hintDangles(pass, hint),
+											Deprecated: deprecated,
+											Pos:        pass.Fset.Position(q.Pos()),
+										}
+										if q.Comment != nil {
+											c.HintPos = pass.Fset.Position(q.Comment.Pos())
+										} else {
+											c.end = q.End()
+										}
+										if obj := pass.TypesInfo.Defs[q.Names[0]]; obj != nil {
+											pass.ExportObjectFact(obj, &eventFact{Name: c.Name, Value: c.Value, Hint: c.Hint, HintPath: c.HintPath})
+										}
+										res.Constants = append(res.Constants, c)
+										if !root {
+											muxEC.Lock()
+											depConstInventory = append(depConstInventory, c)
+											muxEC.Unlock()
+											continue
+										}
+										muxEC.Lock()
+										constInventory = append(constInventory, c)
+										muxEC.Unlock()
+										// A bad hint is this package's problem alone so it
+										// can be a diagnostic, the join's findings can't.
+										if category, message, ok := hintProblem(c); ok && !ignoredAt(ignore, c.Pos) {
+											// Garbage in the comment is best pointed at in the comment.
+											at := q.Pos()
+											if category == catBadHint && q.Comment != nil {
+												at = q.Comment.Pos()
+											}
+											d := analysis.Diagnostic{Pos: at, Category: category, Message: message}
+											if category == catMissingHint {
+												missing = append(missing, d)
+												continue
+											}
+											pass.Report(d)
+										}
 									}
 								}
 							}
@@ -156,78 +1361,232 @@ func run(pass *analysis.Pass) (interface{}, error) {
 			return true
 		})
 	}
-	return nil, nil
+	for _, pc := range viaParams {
+		resolveParamCall(pass, pc, callsTo[pc.param.fn])
+	}
+	for _, fc := range calls {
+		checkFactCall(pass, fc, ignore)
+	}
+	// The call sites wait until now so a binding we gave up on in one file
+	// can be told apart from one we never saw, once we've seen every file.
+	for n, call := range res.CallSites {
+		if truncated[call.Emitter] && len(res.Emitters[call.Emitter]) == 0 {
+			res.CallSites[n].Reason = reasonDepth
+		}
+	}
+	if root {
+		mux.Lock()
+		callInventory = append(callInventory, res.CallSites...)
+		mux.Unlock()
+	}
+	// The hints we can infer from this package alone, the ones emitted from
+	// elsewhere only the join knows about, see `-fix`.
+	fixes := make(map[token.Position]analysis.SuggestedFix)
+	inferred := InferHints(JoinInventories(bound, res.Constants, res.CallSites), res.Constants)
+	for n, fx := range hintFixes(inferred) {
+		fixes[inferred[n].Const.Pos] = fx
+	}
+	for _, d := range missing {
+		if fx, ok := fixes[pass.Fset.Position(d.Pos)]; ok {
+			d.SuggestedFixes = []analysis.SuggestedFix{fx}
+		}
+		pass.Report(d)
+	}
+	return res, nil
+}
+
+// wired notes that the field or package-level var `i` refers to, if it is
+// one, has been set somewhere.
+func wired(pass *analysis.Pass, i *ast.Ident) {
+	v, ok := pass.TypesInfo.ObjectOf(i).(*types.Var)
+	if !ok || !(v.IsField() || isPackageLevel(v)) || !isRoot(pass.Pkg) {
+		return
+	}
+	mux.Lock()
+	wiredInventory = append(wiredInventory, fieldKey(pass.Fset.Position(v.Pos()), v.Name()))
+	mux.Unlock()
+}
+
+// optionEmitters maps `-option-constructors` function names to the emitters
+// they set, filled in once the flags are parsed.
+var optionEmitters map[string]string
+
+// parseOptionConstructors parses `-option-constructors`, where each entry is
+// `WithUserEvent=userEvent` or just `WithUserEvent`, which means `userEvent`.
+func parseOptionConstructors(spec string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		fn, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			name = strings.TrimPrefix(fn, "With")
+			if name == "" {
+				continue
+			}
+			name = strings.ToLower(name[:1]) + name[1:]
+		}
+		out[fn] = name
+	}
+	return out
+}
+
+// optionEmitter gives the emitter an option constructor call sets, going by
+// the function's name, `WithUserEvent` or `svc.WithUserEvent`.
+func optionEmitter(fun ast.Expr) (string, bool) {
+	var name string
+	switch f := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		name = f.Name
+	case *ast.SelectorExpr:
+		name = f.Sel.Name
+	default:
+		return "", false
+	}
+	emitter, ok := optionEmitters[name]
+	return emitter, ok
+}
+
+// addBinding appends an event to an emitter's bindings unless it's already there.
+func addBinding(bindings []string, event string) []string {
+	for _, b := range bindings {
+		if b == event {
+			return bindings
+		}
+	}
+	return append(bindings, event)
+}
+
+// payload picks the payload out of an emitter call's arguments by
+// `-payload-arg`.  It's the last one by default, after the event type and
+// whatever metadata, eg. `settings` in
+// `s.userEvent(rabbitEvents.Create, md, auth.UserID, nil, settings)`.
+func payload(args []ast.Expr) (ast.Expr, bool) {
+	n := *payloadArg
+	if n < 0 {
+		n += len(args)
+	}
+	if n < 0 || n >= len(args) {
+		return nil, false
+	}
+	return args[n], true
 }
 
+// payloadArgSet is whether `-payload-arg` was given, in which case it's
+// taken at its word whatever the emitter's signature says.
+var payloadArgSet bool
+
+// callPayload is `payload` with the emitter's signature taken into account.
+// Usually the payload is one of the variadic arguments, the last by default,
+// but functional options on the end, `opts ...Option` with `Option` a func
+// type, aren't anything we emit, so then it's the last fixed argument, eg.
+// `settings` in `s.userEvent(rabbitEvents.Create, settings, WithRetry(3))`.
+func callPayload(pass *analysis.Pass, ce *ast.CallExpr) (ast.Expr, bool) {
+	t := pass.TypesInfo.TypeOf(ce.Fun)
+	if t == nil || payloadArgSet {
+		return payload(ce.Args)
+	}
+	sig, ok := t.Underlying().(*types.Signature)
+	if !ok {
+		return payload(ce.Args)
+	}
+	n, ok := optionsParam(sig)
+	if !ok {
+		return payload(ce.Args)
+	}
+	if len(ce.Args) < n {
+		return nil, false
+	}
+	return ce.Args[n-1], true
+}
+
+// Why something didn't come apart as an emitter, a constructor or a payload.
+// Callers branch on these, so they're sentinels rather than messages.
+var (
+	errNotSelector    = errors.New("not a selector")
+	errNotIdent       = errors.New("not an identifier or a chain of them")
+	errNotEmitter     = errors.New("not an emitter field or var")
+	errNotConstructor = errors.New("not an events constructor")
+	errNoConcrete     = errors.New("not declared with anything concrete")
+	errDepth          = errors.New("more than -resolve-depth locals back")
+)
+
+// selectorParts splits `x.y` into `x` and `y`.  A deeper chain keeps all but
+// the last selector together, so `s.cfg.userEvent` is `s.cfg` and `userEvent`.
 func selectorParts(sel interface{}) (string, string, error) {
 	if se, ok := sel.(*ast.SelectorExpr); ok {
-		if i, ok := se.X.(*ast.Ident); ok {
-			return i.Name, se.Sel.Name, nil
-		}
-	}
-	return "", "", errors.New("bork")
-}
-
-// This is horrible. I can only apologise but this is what AST forces you into.
-func typeFromObj(o *ast.Object, tag string) (string, error) {
-	if o != nil {
-		ei, ese := "pkg-"+tag, "sel-"+tag
-		ei = fmt.Sprintf("pkg-%s-%T\n", tag, o.Decl)
-		if f, ok := o.Decl.(*ast.FuncDecl); ok {
-			if f.Type.Results != nil {
-				if len(f.Type.Results.List) > 0 {
-					r := f.Type.Results.List[0]
-					//					if t, ok := r.Type
-					fmt.Printf("%s => %T %T\n", tag, r, r.Type)
-					if st, ok := r.Type.(*ast.StarExpr); ok {
-						sti, stse, err := selectorParts(st.X)
-						if err == nil {
-							fmt.Printf("%s ASSIGN\n", tag)
-							ei, ese = sti, stse
-						}
-					}
-				}
-			}
+		if x, ok := selectorPath(se.X); ok {
+			return x, se.Sel.Name, nil
 		}
-		if f, ok := o.Decl.(*ast.Field); ok {
-			if st, ok := f.Type.(*ast.StarExpr); ok {
-				sti, stse, err := selectorParts(st.X)
-				if err == nil {
-					// fmt.Printf("%s ASTFIELD\n", tag)
-					ei, ese = sti, stse
-				}
-			}
+		return "", "", errNotIdent
+	}
+	return "", "", errNotSelector
+}
+
+// selectorPath gives the dotted path of an identifier or a chain of selectors
+// on one, eg. `a.b.c`, and false for anything else.
+func selectorPath(e ast.Expr) (string, bool) {
+	switch x := e.(type) {
+	case *ast.Ident:
+		return x.Name, true
+	case *ast.SelectorExpr:
+		if p, ok := selectorPath(x.X); ok {
+			return p + "." + x.Sel.Name, true
 		}
-		if f, ok := o.Decl.(*ast.AssignStmt); ok {
-			if l, ok := f.Lhs[0].(*ast.Ident); ok {
-				_ = l
-				// fmt.Printf("0===> %s %T\n", tag, f.Rhs[0])
-				if r, ok := f.Rhs[0].(*ast.Ident); ok {
-					q, err := typeFromObj(r.Obj, tag+"-rhs")
-					if err == nil {
-						// fmt.Printf("1===> %s RHS %s\n", tag, q)
-						_ = q
-					}
-				}
-				if ce, ok := f.Rhs[0].(*ast.CallExpr); ok {
-					fi, fse, err := selectorParts(ce.Fun)
-					if err == nil {
-						// fmt.Printf("2===> %s RHS %s.%s %T\n", tag, fi, fse, ce.Fun)
-						_, _ = fi, fse
-					} else {
-						if i, ok := ce.Fun.(*ast.Ident); ok {
-							t, err := typeFromObj(i.Obj, tag+"-rhs-obj")
-							if err == nil {
-								// fmt.Printf("3===> %s RHS %T %v %s %s\n", tag, ce.Fun, ce.Fun, i.Name, t)
-								return t, nil
-							}
-						}
+	}
+	return "", false
+}
 
-					}
-				}
+// fieldValue digs the value at `path` out of a composite literal, so with a
+// path of `Meta.Name`, `EventType{Meta: Meta{Name: "user.created"}}` gives us
+// `"user.created"`.
+func fieldValue(cl *ast.CompositeLit, path []string) (ast.Expr, bool) {
+	for _, elt := range cl.Elts {
+		kve, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if k, ok := kve.Key.(*ast.Ident); !ok || k.Name != path[0] {
+			continue
+		}
+		v := kve.Value
+		if u, ok := v.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			v = u.X
+		}
+		if len(path) == 1 {
+			return v, true
+		}
+		if next, ok := v.(*ast.CompositeLit); ok {
+			return fieldValue(next, path[1:])
+		}
+	}
+	return nil, false
+}
+
+// eventsSelectorParts is `selectorParts` with the qualifier resolved through
+// type info, so it's the real package name whatever the file imported it as:
+// `re.Emit(t.EventFoo)` reads as `rabbitEvents.Emit(types.EventFoo)`, and so
+// does `Emit(EventFoo)` with both dot-imported.  Inside the events package a
+// bare identifier counts as qualified by the package, so `Emit(EventFoo)`
+// there reads the same too, and so does one of this package's own constants
+// anywhere.
+func eventsSelectorParts(pass *analysis.Pass, e ast.Expr) (string, string, error) {
+	switch x := e.(type) {
+	case *ast.Ident:
+		obj := pass.TypesInfo.Uses[x]
+		if obj != nil && obj.Pkg() != nil && (obj.Pkg() != pass.Pkg || pass.Pkg.Path() == *eventsPkg) {
+			return obj.Pkg().Name(), x.Name, nil
+		}
+		if c, ok := obj.(*types.Const); ok && c.Pkg() == pass.Pkg {
+			return c.Pkg().Name(), x.Name, nil
+		}
+	case *ast.SelectorExpr:
+		if i, ok := x.X.(*ast.Ident); ok {
+			if pn, ok := pass.TypesInfo.Uses[i].(*types.PkgName); ok {
+				return pn.Imported().Name(), x.Sel.Name, nil
 			}
 		}
-		return ei + "." + ese, nil
 	}
-	return "", errors.New("typeFromObj")
+	return selectorParts(e)
 }
@@ -0,0 +1,756 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestMain makes the test binary the command when `EMITTERANALYSIS_MAIN` is
+// set, so `runCLI` can run it whole, flags, exit code and all.  With
+// `EMITTERANALYSIS_FINALIZER` too it registers a finalizer writing what it
+// was given to that file, for `TestFinalizer`.
+func TestMain(m *testing.M) {
+	if os.Getenv("EMITTERANALYSIS_MAIN") != "" {
+		if path := os.Getenv("EMITTERANALYSIS_FINALIZER"); path != "" {
+			RegisterFinalizer(func(r Result) {
+				b, err := json.Marshal(r)
+				if err == nil {
+					err = os.WriteFile(path, b, 0o644)
+				}
+				if err != nil {
+					panic(err)
+				}
+			})
+		}
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// cliRun is one run of the command: what it wrote and how it exited.
+type cliRun struct {
+	stdout, stderr string
+	code           int
+}
+
+// runCLI runs the command over `testdata/src` in GOPATH mode, from there so
+// relative paths in the output are relative to it, with `stdin` on stdin.
+func runCLI(t *testing.T, stdin string, args ...string) cliRun {
+	t.Helper()
+	gopath, err := filepath.Abs(analysistest.TestData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runCLIIn(t, filepath.Join(gopath, "src"), []string{"GOPATH=" + gopath, "GO111MODULE=off"}, stdin, args...)
+}
+
+// runCLIIn is `runCLI` from `dir` with `env` on top of ours, for the module
+// fixtures.
+func runCLIIn(t *testing.T, dir string, env []string, stdin string, args ...string) cliRun {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "EMITTERANALYSIS_MAIN=1", "GOFLAGS=", "NO_COLOR="), env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	var r cliRun
+	var exit *exec.ExitError
+	if err := cmd.Run(); errors.As(err, &exit) {
+		r.code = exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	r.stdout, r.stderr = stdout.String(), stderr.String()
+	return r
+}
+
+// TestAnalyzer runs the passes over `testdata/src` the way any other driver
+// would, no `main`, so this is the diagnostics and facts on their own.
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), EmitterAnalysis, "services", "types", "clean", "depth", "conditional", "vendoring", "payloads", "promoted", "multifile", "locals", "aliases", "constvalues", "impls", "legacy")
+}
+
+// TestSuggestedFixes checks the hints the passes can work out on their own
+// come with a fix that adds them, see `hints.go.golden`.
+func TestSuggestedFixes(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), EmitterAnalysis, "hints")
+}
+
+func TestSummaryOnly(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+		code int
+	}{
+		{"clean", []string{"clean"}, "OK\n", 0},
+		{"failing", []string{"services"}, "FAIL: 12 mismatches, 0 unresolved, 12 errors (fail threshold 0)\n", exitFindings},
+		// The line says what the exit code does, whatever the counts.
+		{"under threshold", []string{"-fail-threshold", "12", "services"}, "OK: 12 mismatches, 0 unresolved, 12 errors (fail threshold 12)\n", 0},
+		// Only errors fail the run, and these aren't any more.
+		{"downgraded", []string{"-severity", "mismatch=warning", "services"}, "OK: 12 mismatches, 0 unresolved, 0 errors (fail threshold 0)\n", 0},
+		// The path-qualified hints' mismatches are only package names apart.
+		{"path hints by base", []string{"-pkg-match", "base", "pathevents", "pathhints"}, "OK\n", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := runCLI(t, "", append([]string{"-summary-only"}, tc.args...)...)
+			if r.stdout != tc.want || r.code != tc.code {
+				t.Errorf("got %q exiting %d, want %q exiting %d\n%s", r.stdout, r.code, tc.want, tc.code, r.stderr)
+			}
+		})
+	}
+}
+
+// TestFailThreshold runs `services`, which has 12 error findings, either side
+// of and at the threshold.
+func TestFailThreshold(t *testing.T) {
+	for _, tc := range []struct {
+		threshold string
+		code      int
+	}{
+		{"11", exitFindings},
+		{"12", 0},
+		{"13", 0},
+	} {
+		t.Run(tc.threshold, func(t *testing.T) {
+			r := runCLI(t, "", "-fail-threshold", tc.threshold, "services")
+			if r.code != tc.code {
+				t.Errorf("exited %d, want %d\n%s", r.code, tc.code, r.stderr)
+			}
+		})
+	}
+}
+
+// TestMissingHintsCount checks the count is all there is on stdout, a number
+// and a newline, `hints` having the one constant without a hint.
+func TestMissingHintsCount(t *testing.T) {
+	for _, tc := range []struct {
+		pkg  string
+		want string
+		code int
+	}{
+		{"types", "0\n", 0},
+		{"hints", "1\n", exitFindings},
+	} {
+		t.Run(tc.pkg, func(t *testing.T) {
+			r := runCLI(t, "", "-report-missing-hints-count-only", tc.pkg)
+			if r.stdout != tc.want || r.code != tc.code {
+				t.Errorf("got %q exiting %d, want %q exiting %d\n%s", r.stdout, r.code, tc.want, tc.code, r.stderr)
+			}
+			if _, err := strconv.Atoi(strings.TrimSuffix(r.stdout, "\n")); err != nil {
+				t.Errorf("output isn't an integer: %v", err)
+			}
+		})
+	}
+}
+
+// TestTimings checks each phase gets a line on stderr, in the order they run,
+// and that stdout is the same as without them.
+func TestTimings(t *testing.T) {
+	r := runCLI(t, "", "-timings", "clean")
+	var phases []string
+	for _, line := range strings.Split(r.stderr, "\n") {
+		if f := strings.Fields(line); len(f) == 3 && f[0] == "timing:" {
+			if _, err := time.ParseDuration(f[2]); err != nil {
+				t.Errorf("%q: %v", line, err)
+			}
+			phases = append(phases, f[1])
+		}
+	}
+	if want := []string{"load", "analyze", "consts", "emitters", "join", "total"}; !reflect.DeepEqual(phases, want) {
+		t.Errorf("got phases %v, want %v\n%s", phases, want, r.stderr)
+	}
+	if plain := runCLI(t, "", "clean"); r.stdout != plain.stdout || r.code != plain.code {
+		t.Errorf("got %q exiting %d, want %q exiting %d", r.stdout, r.code, plain.stdout, plain.code)
+	}
+}
+
+// TestOnly runs `reportall`, which has one finding in each of its categories,
+// with `-only` each of them, so each run should print just the one.
+func TestOnly(t *testing.T) {
+	for _, category := range []string{catUnusedEmitter, catBadHint, catMissingHint, catDanglingHint} {
+		t.Run(category, func(t *testing.T) {
+			r := runCLI(t, "", "-format", "json", "-only", category, "reportall")
+			var out struct{ Findings []Finding }
+			if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+				t.Fatalf("%v\n%s", err, r.stderr)
+			}
+			if len(out.Findings) != 1 || out.Findings[0].Category != category {
+				t.Errorf("got %+v, want the one %s finding", out.Findings, category)
+			}
+		})
+	}
+	if r := runCLI(t, "", "-only", "nope", "reportall"); r.code != exitError || !strings.Contains(r.stderr, `unknown -only category "nope"`) {
+		t.Errorf("unknown category exited %d\n%s", r.code, r.stderr)
+	}
+}
+
+// TestErrorsOnStderr checks `-format json` keeps stdout a document to parse
+// with the errors on stderr: a type error, which is only a warning, and a
+// package that isn't there, which goes in the document's errors too.
+func TestErrorsOnStderr(t *testing.T) {
+	r := runCLI(t, "", "-format", "json", "broken")
+	var out struct {
+		Findings []Finding
+		Errors   []string
+	}
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+		t.Fatalf("stdout isn't JSON: %v\n%s", err, r.stdout)
+	}
+	if strings.Contains(r.stdout, "NoSuchType") || !strings.Contains(r.stderr, "undefined: types.NoSuchType") {
+		t.Errorf("the type error should be on stderr only\nstdout:\n%s\nstderr:\n%s", r.stdout, r.stderr)
+	}
+
+	r = runCLI(t, "", "-format", "json", "nosuchpkg")
+	out.Errors = nil
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+		t.Fatalf("stdout isn't JSON: %v\n%s", err, r.stdout)
+	}
+	if len(out.Errors) != 1 || !strings.Contains(out.Errors[0], `cannot find package "nosuchpkg"`) || r.code != exitError {
+		t.Errorf("got errors %q exiting %d, want the missing package exiting %d", out.Errors, r.code, exitError)
+	}
+	if !strings.Contains(r.stderr, `cannot find package "nosuchpkg"`) {
+		t.Errorf("the missing package isn't on stderr\n%s", r.stderr)
+	}
+}
+
+// TestNoPackages runs over a pattern that loads nothing once the vendored
+// package is dropped, so `-fix` and `-tui` can't take the file set from the
+// first package.
+func TestNoPackages(t *testing.T) {
+	r := runCLI(t, "", "-format", "json", "-fix", "vendoring/vendor/acme")
+	var out struct{ Findings []Finding }
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil || len(out.Findings) != 0 || r.code != 0 {
+		t.Errorf("got %d findings exiting %d, %v\n%s", len(out.Findings), r.code, err, r.stderr)
+	}
+}
+
+// TestDumpConfig checks the dump ends with the pattern constants are matched
+// against, built from `-const-prefix` or given as `-const-regex`.
+func TestDumpConfig(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, `const pattern = "^(?:Event)"`},
+		{[]string{"-const-prefix", "Event,Evt.v2"}, `const pattern = "^(?:Event|Evt\\.v2)"`},
+		{[]string{"-const-regex", "^Topic[A-Z]"}, `const pattern = "^Topic[A-Z]"`},
+	} {
+		r := runCLI(t, "", append([]string{"-dump-config"}, tc.args...)...)
+		lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+		if got := lines[len(lines)-1]; got != tc.want || r.code != 0 {
+			t.Errorf("%v: got %s exiting %d, want %s\n%s", tc.args, got, r.code, tc.want, r.stderr)
+		}
+	}
+}
+
+// TestUnknownType checks what `reportall`'s constant without a comment gets
+// for a hint under `-unknown-type`, and that it's a missing hint whatever it
+// gets.
+func TestUnknownType(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "types.UnknownEventType"},
+		{[]string{"-unknown-type", "events.Unhinted"}, "events.Unhinted"},
+		{[]string{"-unknown-type", ""}, ""},
+	} {
+		r := runCLI(t, "", append(append([]string{"-json"}, tc.args...), "reportall")...)
+		var records []record
+		if err := json.Unmarshal([]byte(r.stdout), &records); err != nil {
+			t.Fatalf("%v\n%s", err, r.stderr)
+		}
+		found := false
+		for _, rec := range records {
+			if rec.Kind == recordConst && rec.Event == "reportall.EventPathSilence" {
+				found = true
+				if rec.DeclaredType != tc.want {
+					t.Errorf("%v: got hint %q, want %q", tc.args, rec.DeclaredType, tc.want)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%v: no record for reportall.EventPathSilence", tc.args)
+		}
+
+		r = runCLI(t, "", append(append([]string{"-only", catMissingHint}, tc.args...), "reportall")...)
+		if !strings.Contains(r.stdout, "reportall.EventPathSilence has no type hint comment") {
+			t.Errorf("%v: not reported as a missing hint\n%s", tc.args, r.stdout)
+		}
+	}
+}
+
+// TestListUnresolved runs `reasons`, which has a payload for each reason we
+// can't resolve one, and `depth` for the last, checking every call gets its
+// reason and they're listed by reason.
+func TestListUnresolved(t *testing.T) {
+	r := runCLI(t, "", "-list-unresolved", "-payload-arg", "1", "-resolve-depth", "1", "depth", "reasons")
+	var got []string
+	for _, line := range strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n") {
+		// file:line:col: reason: emitter
+		parts := strings.SplitN(line, ": ", 3)
+		if len(parts) != 3 {
+			t.Fatalf("bad line %q", line)
+		}
+		pos := strings.Split(filepath.ToSlash(parts[0]), "/")
+		got = append(got, pos[len(pos)-1]+" "+parts[1]+" "+parts[2])
+	}
+	want := []string{
+		"depth.go:28:15 depth second",
+		"depth.go:32:20 depth s.userEvent",
+		"reasons.go:21:21 dynamic s.orderEvent",
+		"reasons.go:35:21 no-payload-arg s.orderEvent",
+		"reasons.go:26:21 payload-param s.orderEvent",
+		"reasons.go:31:21 uninstantiated-type-param s.orderEvent",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"), r.stderr)
+	}
+}
+
+// TestMods joins across `testdata/mods`, one module with the events package
+// and the constants and another emitting them.  The constants only get into
+// the join because both are loaded.
+func TestMods(t *testing.T) {
+	dir, err := filepath.Abs(analysistest.TestData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := runCLIIn(t, dir, []string{"GO111MODULE=on", "GOWORK=off", "GOPROXY=off"}, "", "-json", "-mods", "mods/events,mods/billing")
+	var records []record
+	if err := json.Unmarshal([]byte(r.stdout), &records); err != nil {
+		t.Fatalf("%v\n%s", err, r.stderr)
+	}
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.Kind+" "+rec.Pkg+" "+rec.Event+rec.Emitter)
+	}
+	want := []string{
+		"const example.com/events/types example.com/events/types.EventPathInvoice",
+		"const example.com/events/types example.com/events/types.EventPathRefund",
+		"emitter example.com/billing example.com/events/types.EventPathInvoiceinvoice",
+		"emitter example.com/billing example.com/events/types.EventPathRefundrefund",
+		"callsite example.com/billing s.invoice",
+		"callsite example.com/billing s.refund",
+		"mismatch example.com/billing example.com/events/types.EventPathRefunds.refund",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"), r.stderr)
+	}
+	if r.code != exitFindings {
+		t.Errorf("exited %d, want %d for the mismatch", r.code, exitFindings)
+	}
+}
+
+// TestJSONExitCode checks `-json` leaves out the mismatches a baseline
+// suppresses and exits the way the other formats do: 1 for error findings
+// over the threshold, 2 for a package that won't load.
+func TestJSONExitCode(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	if r := runCLI(t, "", "-write-baseline", "-baseline", baseline, "services"); r.code != 0 {
+		t.Fatalf("writing the baseline exited %d\n%s", r.code, r.stderr)
+	}
+	for _, tc := range []struct {
+		name       string
+		args       []string
+		mismatches bool
+		code       int
+	}{
+		{"default", nil, true, exitFindings},
+		{"under the threshold", []string{"-fail-threshold", "100"}, true, 0},
+		{"downgraded", []string{"-severity", "mismatch=warning"}, true, 0},
+		{"baseline", []string{"-baseline", baseline}, false, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := runCLI(t, "", append(append([]string{"-json"}, tc.args...), "services")...)
+			var records []record
+			if err := json.Unmarshal([]byte(r.stdout), &records); err != nil {
+				t.Fatalf("%v\n%s", err, r.stderr)
+			}
+			mismatches := false
+			for _, rec := range records {
+				mismatches = mismatches || rec.Kind == recordMismatch
+			}
+			if mismatches != tc.mismatches || r.code != tc.code {
+				t.Errorf("got mismatch records %v exiting %d, want %v exiting %d\n%s", mismatches, r.code, tc.mismatches, tc.code, r.stderr)
+			}
+		})
+	}
+	if r := runCLI(t, "", "-json", "nosuchpkg"); r.code != exitError {
+		t.Errorf("nosuchpkg exited %d, want %d", r.code, exitError)
+	}
+}
+
+// TestColor checks only `-color` gets escape codes into text findings, and
+// that `-no-color` and `NO_COLOR` both win over it.
+func TestColor(t *testing.T) {
+	gopath, err := filepath.Abs(analysistest.TestData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name    string
+		env     []string
+		args    []string
+		escapes bool
+	}{
+		{"not a terminal", nil, nil, false},
+		{"-color", nil, []string{"-color"}, true},
+		{"-no-color", nil, []string{"-color", "-no-color"}, false},
+		{"NO_COLOR", []string{"NO_COLOR=1"}, []string{"-color"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := append([]string{"GOPATH=" + gopath, "GO111MODULE=off"}, tc.env...)
+			r := runCLIIn(t, filepath.Join(gopath, "src"), env, "", append(tc.args, "services")...)
+			if r.stdout == "" {
+				t.Fatalf("no findings\n%s", r.stderr)
+			}
+			if got := strings.Contains(r.stdout, "\x1b["); got != tc.escapes {
+				t.Errorf("escape codes %v, want %v\n%q", got, tc.escapes, r.stdout)
+			}
+		})
+	}
+}
+
+// TestCrossPackageEmissions runs `orders`, which emits one of its events
+// itself, and `shipping`, which emits the other twice, so only the second
+// couples anything.
+func TestCrossPackageEmissions(t *testing.T) {
+	r := runCLI(t, "", "-report-cross-package-emissions", "orders", "shipping")
+	if want := "orders.EventPathShipped (orders)\n\tshipping\t2\n"; r.stdout != want || r.code != 0 {
+		t.Errorf("got %q exiting %d, want %q\n%s", r.stdout, r.code, want, r.stderr)
+	}
+}
+
+// TestFinalizer checks a finalizer gets the whole run: every finding, even
+// with `-only` narrowing what's printed, and the inventories behind them.
+func TestFinalizer(t *testing.T) {
+	gopath, err := filepath.Abs(analysistest.TestData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "result.json")
+	env := []string{"GOPATH=" + gopath, "GO111MODULE=off", "EMITTERANALYSIS_FINALIZER=" + path}
+	r := runCLIIn(t, filepath.Join(gopath, "src"), env, "", "-only", catBadHint, "reportall")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, r.stderr)
+	}
+	var got Result
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Consts) != 4 || len(got.Emitters) != 2 || len(got.Calls) != 1 {
+		t.Errorf("got %d constants, %d emitters and %d calls, want 4, 2 and 1", len(got.Consts), len(got.Emitters), len(got.Calls))
+	}
+	if len(got.Findings) != 4 {
+		t.Errorf("got findings %+v, want all four of them", got.Findings)
+	}
+}
+
+// TestRequireHints runs `unhinted`, whose constant has neither a doc nor a
+// comment, so it gets the sentinel for a hint and only `-require-hints` makes
+// that an error.
+func TestRequireHints(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want int
+		code int
+	}{
+		{nil, 0, 0},
+		{[]string{"-require-hints"}, 1, exitFindings},
+	} {
+		r := runCLI(t, "", append(append([]string{"-format", "json"}, tc.args...), "unhinted")...)
+		var out struct{ Findings []Finding }
+		if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+			t.Fatalf("%v\n%s", err, r.stderr)
+		}
+		if len(out.Findings) != tc.want || r.code != tc.code {
+			t.Fatalf("%v: got %d findings exiting %d, want %d exiting %d\n%s", tc.args, len(out.Findings), r.code, tc.want, tc.code, r.stdout)
+		}
+		for _, f := range out.Findings {
+			if f.Category != catMissingHint || f.Severity != sevError || f.Line != 5 || !reflect.DeepEqual(f.DeclaredTypes, []string{"types.UnknownEventType"}) {
+				t.Errorf("%v: got %+v, want an error for the missing hint on line 5", tc.args, f)
+			}
+		}
+	}
+}
+
+// TestGolden compares the documents written about `conditional` with their
+// copies in `testdata`.
+func TestGolden(t *testing.T) {
+	for _, tc := range []struct {
+		golden string
+		args   []string
+	}{
+		{"conditional.md", []string{"-format", "markdown"}},
+		// The dry run has every call against every binding, matched or not.
+		{"conditional.join", []string{"-show-join"}},
+	} {
+		t.Run(tc.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join(analysistest.TestData(), tc.golden))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := runCLI(t, "", append(tc.args, "conditional")...); r.stdout != string(want) || r.code != 0 {
+				t.Errorf("got\n%s\nexiting %d, want\n%s\n%s", r.stdout, r.code, want, r.stderr)
+			}
+		})
+	}
+}
+
+// TestEveryReport runs every report over every fixture at once, which is as
+// many passes filling the inventories at the same time as we can manage.
+// Under `go test -race` the command is built with the race detector too, and
+// it says so on stderr if they trip over each other.
+func TestEveryReport(t *testing.T) {
+	args := []string{
+		"-format", "json", "-report-all", "-report-inconsistent-prefixes", "-report-unwired",
+		"-report-hint-type-drift", "-report-naming", "-detect-conflicts", "-report-unused", "-report-uncalled",
+		"services", "types", "clean", "conditional", "payloads", "promoted", "multifile", "locals", "aliases",
+		"constvalues", "reportall", "announce", "listener", "impls", "deprecation", "drift", "naming", "wiring", "orders", "shipping", "stdhints",
+	}
+	r := runCLI(t, "", args...)
+	if strings.Contains(r.stderr, "DATA RACE") {
+		t.Fatalf("race\n%s", r.stderr)
+	}
+	var out struct{ Findings []Finding }
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil || len(out.Findings) == 0 {
+		t.Errorf("got %d findings, %v\n%s", len(out.Findings), err, r.stderr)
+	}
+}
+
+// TestPrefixesWithRegex runs `prefixevents` the way the "mixed prefixes"
+// findings do but with `-const-regex`, which can't say where a prefix ends,
+// so the report is skipped rather than making every constant an outlier.
+func TestPrefixesWithRegex(t *testing.T) {
+	r := runCLI(t, "", "-format", "json", "-const-regex", `^(Event|Evt|Topic)\w+`, "-only", catInconsistentPrefix, "prefixevents", "prefixes")
+	var out struct{ Findings []Finding }
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil || len(out.Findings) != 0 {
+		t.Errorf("got %d findings, %v\n%s", len(out.Findings), err, r.stdout)
+	}
+	if !strings.Contains(r.stderr, "skipping it with -const-regex") {
+		t.Errorf("no word of skipping the report\n%s", r.stderr)
+	}
+}
+
+// TestStdinFiles pipes in file lists, with patterns giving the join the rest
+// of the package and without, where the files' packages are all there is.
+// Either way only findings in the listed files count.
+func TestStdinFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		stdin string
+		args  []string
+		want  []string
+	}{
+		{"with patterns", "payloads/chains.go\n\npayloads/maps.go\n", []string{"payloads"}, []string{"chains.go:15", "maps.go:15"}},
+		{"without", "multifile/b.go\n", nil, []string{"b.go:21"}},
+		{"nothing there", "multifile/a.go\n", nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := runCLI(t, tc.stdin, append([]string{"-format", "json", "-stdin-files"}, tc.args...)...)
+			var out struct{ Findings []Finding }
+			if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+				t.Fatalf("%v\n%s", err, r.stderr)
+			}
+			var got []string
+			for _, f := range out.Findings {
+				got = append(got, filepath.Base(f.File)+":"+strconv.Itoa(f.Line))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got findings at %v, want %v\n%s", got, tc.want, r.stderr)
+			}
+		})
+	}
+}
+
+// TestNDJSON checks every line is a finding on its own and that they're the
+// same findings `-format json` gives.
+func TestNDJSON(t *testing.T) {
+	r := runCLI(t, "", "-format", "ndjson", "services")
+	var out struct{ Findings []Finding }
+	if err := json.Unmarshal([]byte(runCLI(t, "", "-format", "json", "services").stdout), &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+	if len(lines) != len(out.Findings) {
+		t.Fatalf("got %d lines, want %d\n%s", len(lines), len(out.Findings), r.stdout)
+	}
+	for n, line := range lines {
+		var f Finding
+		if err := json.Unmarshal([]byte(line), &f); err != nil {
+			t.Errorf("line %d: %v\n%s", n+1, err, line)
+			continue
+		}
+		if f.Message != out.Findings[n].Message || f.Line != out.Findings[n].Line {
+			t.Errorf("line %d is %s:%d %s, want %s:%d %s", n+1, f.File, f.Line, f.Message, out.Findings[n].File, out.Findings[n].Line, out.Findings[n].Message)
+		}
+	}
+}
+
+func TestJSONCallSites(t *testing.T) {
+	r := runCLI(t, "", "-json", "services")
+	var records []record
+	if err := json.Unmarshal([]byte(r.stdout), &records); err != nil {
+		t.Fatalf("%v\n%s", err, r.stderr)
+	}
+	calls := 0
+	for _, rec := range records {
+		if rec.Kind != recordCallSite {
+			continue
+		}
+		calls++
+		// The constructors and plain methods are calls, but not through an
+		// emitter.
+		switch rec.Emitter {
+		case "rabbitEvents.Emit", "rabbitEvents.WithRetries", "s.updateUserSettings":
+			t.Errorf("%s:%d: call site for %s, which isn't an emitter", rec.File, rec.Line, rec.Emitter)
+		}
+	}
+	if calls != 26 {
+		t.Errorf("got %d call sites, want 26", calls)
+	}
+}
+
+// findingMarker is how a fixture says what the join should find on a line,
+// `// finding category "regexp"`, for the findings that aren't the passes'
+// diagnostics and so can't be a `// want`.  A line can have several and they
+// can go before a `// want`.
+var findingMarker = regexp.MustCompile("// finding ([a-z-]+) (`[^`]*`|\"(?:[^\"\\\\]|\\\\.)*\")")
+
+// checkFindings runs the command with `-format json` and `args` over `pkgs`
+// and checks the findings in `categories` against the markers in the
+// packages' files: each finding has to match a marker on its line and each
+// marker a finding.
+func checkFindings(t *testing.T, pkgs, categories []string, args ...string) {
+	t.Helper()
+	type key struct {
+		file     string
+		line     int
+		category string
+	}
+	want := make(map[key][]*regexp.Regexp)
+	wanted := make(map[string]bool)
+	for _, c := range categories {
+		wanted[c] = true
+	}
+	for _, pkg := range pkgs {
+		files, err := filepath.Glob(filepath.Join(analysistest.TestData(), "src", pkg, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n, line := range strings.Split(string(b), "\n") {
+				for _, m := range findingMarker.FindAllStringSubmatch(line, -1) {
+					if !wanted[m[1]] {
+						continue
+					}
+					pattern, err := strconv.Unquote(m[2])
+					if err != nil {
+						t.Fatalf("%s:%d: %v", file, n+1, err)
+					}
+					k := key{abs, n + 1, m[1]}
+					want[k] = append(want[k], regexp.MustCompile(pattern))
+				}
+			}
+		}
+	}
+
+	r := runCLI(t, "", append(append([]string{"-format", "json"}, args...), pkgs...)...)
+	var out struct{ Findings []Finding }
+	if err := json.Unmarshal([]byte(r.stdout), &out); err != nil {
+		t.Fatalf("%v\n%s", err, r.stderr)
+	}
+	for _, f := range out.Findings {
+		if !wanted[f.Category] {
+			continue
+		}
+		k := key{f.File, f.Line, f.Category}
+		matched := false
+		for n, rx := range want[k] {
+			if rx.MatchString(f.Message) {
+				want[k] = append(want[k][:n], want[k][n+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s:%d: unexpected %s finding: %s", relPath(f.File), f.Line, f.Category, f.Message)
+		}
+	}
+	for k, rxs := range want {
+		for _, rx := range rxs {
+			t.Errorf("%s:%d: no %s finding matching %q", relPath(k.file), k.line, k.category, rx)
+		}
+	}
+}
+
+// joinCategories are the join's verdicts on call sites, which is what most
+// of the fixtures are about.
+var joinCategories = []string{string(KindMismatch), string(KindUnknown), string(KindUnresolved)}
+
+// TestFindings checks the join's findings against the fixtures' markers, a
+// category or few at a time.
+func TestFindings(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		pkgs       []string
+		categories []string
+		args       []string
+	}{
+		{"unbound emitter", []string{"services"}, []string{string(KindUnknown)}, nil},
+		{"resolve depth", []string{"depth"}, []string{string(KindUnresolved)}, []string{"-resolve-depth", "1"}},
+		{"conditional bindings", []string{"conditional"}, joinCategories, nil},
+		// There's a mismatch in the vendored package, which only counts with
+		// `-include-vendor`.
+		{"vendor skipped", []string{"vendoring/..."}, joinCategories, nil},
+		{"vendor included", []string{"vendoring", "vendoring/vendor/acme"}, joinCategories, []string{"-include-vendor"}},
+		// The mismatches are the passes' too, those are `// want`s.
+		{"payloads", []string{"payloads"}, []string{string(KindUnknown), string(KindUnresolved)}, nil},
+		{"embedded interface", []string{"promoted"}, joinCategories, nil},
+		{"bound in two files", []string{"multifile"}, joinCategories, nil},
+		{"events package itself", []string{"bus"}, joinCategories, []string{"-events-pkg", "bus"}},
+		{"report all", []string{"reportall"}, []string{catUnusedEmitter, catBadHint, catMissingHint, catDanglingHint}, []string{"-report-all"}},
+		{"emitters called from elsewhere", []string{"announce", "listener"}, []string{catUnusedEmitter}, []string{"-report-all"}},
+		{"struct-valued events", []string{"wrapped"}, joinCategories, []string{"-events-pkg", "wrapped", "-event-value-field", "Meta.Name"}},
+		{"deprecated event", []string{"deprecation"}, []string{catDeprecated}, nil},
+		{"constructor locals", []string{"locals"}, joinCategories, nil},
+		{"short and path hints", []string{"pathevents", "pathhints"}, joinCategories, nil},
+		{"unwired emitter", []string{"wiring"}, []string{catUnwiredEmitter}, []string{"-report-unwired"}},
+		{"hint drift", []string{"drift"}, []string{catHintDrift}, []string{"-report-hint-type-drift"}},
+		{"builtin and stdlib hints", []string{"stdhints"}, append([]string{catBadHint, catDanglingHint}, joinCategories...), []string{"-report-all"}},
+		{"naming", []string{"naming"}, []string{catNaming}, []string{"-report-naming"}},
+		{"option constructors", []string{"options"}, joinCategories, []string{"-option-constructors", "WithUserEvent,WithOrders=orderEvent"}},
+		{"import aliases", []string{"aliases"}, joinCategories, nil},
+		{"dot-imported events", []string{"legacy"}, joinCategories, nil},
+		{"mixed prefixes", []string{"prefixevents", "prefixes"}, []string{catInconsistentPrefix}, []string{"-const-prefix", "Event,Evt", "-report-inconsistent-prefixes"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkFindings(t, tc.pkgs, tc.categories, tc.args...)
+		})
+	}
+}
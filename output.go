@@ -0,0 +1,875 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis/checker"
+
+	"github.com/pkg/errors"
+)
+
+// Finding is one thing we report, whatever format we're reporting it in.
+type Finding struct {
+	Category      string   `json:"category"`
+	Severity      string   `json:"severity"`
+	Pkg           string   `json:"pkg"`
+	Emitter       string   `json:"emitter,omitempty"`
+	EmittedType   string   `json:"emittedType,omitempty"`
+	DeclaredTypes []string `json:"declaredTypes,omitempty"`
+	Events        []string `json:"events,omitempty"`
+	Reason        string   `json:"reason,omitempty"`
+	SuggestedHint string   `json:"suggestedHint,omitempty"` // for missing-hint, what the call sites say it should be
+	Message       string   `json:"message"`
+	File          string   `json:"file"`
+	Line          int      `json:"line"`
+	Col           int      `json:"col"`
+}
+
+// Finding categories other than the `MismatchKind`s, which are categories too.
+const (
+	catUnusedEmitter = "unused-emitter"
+	catBadHint       = "bad-hint"
+	catMissingHint   = "missing-hint"
+	catDanglingHint  = "dangling-hint"
+	catDeprecated    = "deprecated-event"
+
+	catInconsistentPrefix = "inconsistent-prefix"
+	catUnwiredEmitter     = "unwired-emitter"
+	catHintDrift          = "hint-drift"
+	catNaming             = "naming"
+	catUnusedEvent        = "unused-event"
+	catUncalledEmitter    = "uncalled-emitter"
+	catHintConflict       = "hint-conflict"
+	catUnresolvedImpl     = "unresolved-emitter-impl"
+)
+
+// categories is every finding category there is.
+var categories = []string{
+	string(KindMismatch), string(KindUnknown), string(KindUnresolved),
+	catUnusedEmitter, catBadHint, catMissingHint, catDanglingHint, catDeprecated,
+	catInconsistentPrefix, catUnwiredEmitter, catHintDrift,
+	catNaming, catUnusedEvent, catUncalledEmitter, catHintConflict,
+	catUnresolvedImpl,
+}
+
+// Severities, from blocking to noise.
+const (
+	sevError   = "error"   // something's wrong, fails the run
+	sevWarning = "warning" // worth a look, we can't say it's wrong
+	sevInfo    = "info"    // housekeeping
+)
+
+// defaultSeverities is the severity of each category unless `-severity` says
+// otherwise.  Anything missing is a warning.
+var defaultSeverities = map[string]string{
+	string(KindMismatch):   sevError,
+	catUnwiredEmitter:      sevError,
+	catHintConflict:        sevError,
+	string(KindUnknown):    sevWarning,
+	string(KindUnresolved): sevWarning,
+	catBadHint:             sevWarning,
+	catMissingHint:         sevWarning,
+	catDanglingHint:        sevWarning,
+	catDeprecated:          sevWarning,
+	catHintDrift:           sevWarning,
+	catNaming:              sevWarning,
+	catUnusedEmitter:       sevInfo,
+	catInconsistentPrefix:  sevInfo,
+	catUnusedEvent:         sevInfo,
+	catUncalledEmitter:     sevInfo,
+	catUnresolvedImpl:      sevInfo,
+}
+
+// parseSeverities parses `-severity`, eg. `mismatch=error,unresolved=warning`,
+// into overrides of `defaultSeverities`.
+func parseSeverities(spec string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, kv := range strings.Split(spec, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		cat, sev, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, errors.Errorf("bad -severity %q, want category=severity", kv)
+		}
+		if !isCategory(cat) {
+			return nil, errors.Errorf("unknown -severity category %q", cat)
+		}
+		if sev != sevError && sev != sevWarning && sev != sevInfo {
+			return nil, errors.Errorf("unknown severity %q for %s, want %s, %s or %s", sev, cat, sevError, sevWarning, sevInfo)
+		}
+		out[cat] = sev
+	}
+	return out, nil
+}
+
+// setSeverities gives each finding its category's severity, overrides first.
+func setSeverities(findings []Finding, overrides map[string]string) {
+	for n := range findings {
+		sev, ok := overrides[findings[n].Category]
+		if !ok {
+			sev, ok = defaultSeverities[findings[n].Category]
+		}
+		if !ok {
+			sev = sevWarning
+		}
+		findings[n].Severity = sev
+	}
+}
+
+// isCategory reports whether `name` is one of the finding categories.
+func isCategory(name string) bool {
+	for _, c := range categories {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyCategory keeps the findings in one category.
+func onlyCategory(findings []Finding, category string) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.Category == category {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// inFiles keeps the findings in the files in `files`, which are absolute.
+func inFiles(findings []Finding, files map[string]bool) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if abs, err := filepath.Abs(f.File); err == nil && files[abs] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// mismatchFindings turns the `Join`'s mismatches into findings,
+// keeping its order.
+func mismatchFindings(mismatches []Mismatch) []Finding {
+	findings := make([]Finding, 0, len(mismatches))
+	for _, m := range mismatches {
+		f := Finding{
+			Category:    string(m.Kind),
+			Pkg:         m.Call.Pkg,
+			Emitter:     m.Call.name(),
+			EmittedType: m.Call.Type,
+			Events:      m.Events,
+			Reason:      m.Call.Reason,
+			Message:     m.String(),
+			File:        m.Call.Pos.Filename,
+			Line:        m.Call.Pos.Line,
+			Col:         m.Call.Pos.Column,
+		}
+		for _, w := range m.Wants {
+			f.DeclaredTypes = append(f.DeclaredTypes, w.Hint)
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// emitterFindings reports emitter bindings nothing calls.
+func emitterFindings(unused []Emitter) []Finding {
+	findings := make([]Finding, 0, len(unused))
+	for _, e := range unused {
+		findings = append(findings, Finding{
+			Category: catUnusedEmitter,
+			Pkg:      e.Pkg,
+			Emitter:  e.Name,
+			Events:   []string{e.Event},
+			Message:  fmt.Sprintf("emitter %s is bound to %s but never called", e.Name, shortType(e.Event)),
+			File:     e.Pos.Filename,
+			Line:     e.Pos.Line,
+			Col:      e.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// unusedEventFindings reports event constants nothing emits.
+func unusedEventFindings(consts []EventConst) []Finding {
+	findings := make([]Finding, 0, len(consts))
+	for _, c := range consts {
+		findings = append(findings, Finding{
+			Category: catUnusedEvent,
+			Pkg:      c.Pkg,
+			Events:   []string{c.Name},
+			Message:  fmt.Sprintf("event constant %s is never emitted", shortType(c.Name)),
+			File:     c.Pos.Filename,
+			Line:     c.Pos.Line,
+			Col:      c.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// deprecationFindings warns about each call site that can emit a deprecated event.
+func deprecationFindings(deprecations []Deprecation) []Finding {
+	findings := make([]Finding, 0, len(deprecations))
+	for _, d := range deprecations {
+		findings = append(findings, Finding{
+			Category:      catDeprecated,
+			Pkg:           d.Call.Pkg,
+			Emitter:       d.Call.Recv + "." + d.Call.Emitter,
+			EmittedType:   d.Call.Type,
+			DeclaredTypes: []string{d.Const.Hint},
+			Events:        []string{d.Const.Name},
+			Message:       fmt.Sprintf("%s.%s emits deprecated event %s", d.Call.Recv, d.Call.Emitter, shortType(d.Const.Name)),
+			File:          d.Call.Pos.Filename,
+			Line:          d.Call.Pos.Line,
+			Col:           d.Call.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// unwiredFindings reports emitter fields that are declared but never set.
+func unwiredFindings(fields []EmitterField) []Finding {
+	findings := make([]Finding, 0, len(fields))
+	for _, f := range fields {
+		findings = append(findings, Finding{
+			Category: catUnwiredEmitter,
+			Pkg:      f.Pkg,
+			Emitter:  f.Name,
+			Message:  fmt.Sprintf("%s %s is never set and will be nil when called", f.what(), f.Name),
+			File:     f.Pos.Filename,
+			Line:     f.Pos.Line,
+			Col:      f.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// uncalledFindings reports emitter fields and vars nothing calls.
+func uncalledFindings(fields []EmitterField) []Finding {
+	findings := make([]Finding, 0, len(fields))
+	for _, f := range fields {
+		findings = append(findings, Finding{
+			Category: catUncalledEmitter,
+			Pkg:      f.Pkg,
+			Emitter:  f.Name,
+			Message:  fmt.Sprintf("%s %s is never called", f.what(), f.Name),
+			File:     f.Pos.Filename,
+			Line:     f.Pos.Line,
+			Col:      f.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// driftFindings reports constants whose hints have drifted from what gets
+// emitted, at the constant.
+func driftFindings(drifts []Drift) []Finding {
+	findings := make([]Finding, 0, len(drifts))
+	for _, d := range drifts {
+		findings = append(findings, Finding{
+			Category:      catHintDrift,
+			Pkg:           d.Const.Pkg,
+			EmittedType:   d.Emitted,
+			DeclaredTypes: []string{d.Const.Hint},
+			Events:        []string{d.Const.Name},
+			Message:       fmt.Sprintf("%s wants %s but %d of %d calls emit %s", shortType(d.Const.Name), d.Const.Hint, d.Count, d.Total, shortType(d.Emitted)),
+			File:          d.Const.Pos.Filename,
+			Line:          d.Const.Pos.Line,
+			Col:           d.Const.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// namingFindings reports constants whose names and values disagree.
+func namingFindings(consts []EventConst) []Finding {
+	findings := make([]Finding, 0, len(consts))
+	for _, c := range consts {
+		findings = append(findings, Finding{
+			Category: catNaming,
+			Pkg:      c.Pkg,
+			Events:   []string{c.Name},
+			Message:  fmt.Sprintf("%s has value %q, which doesn't look like its name", shortType(c.Name), c.Value),
+			File:     c.Pos.Filename,
+			Line:     c.Pos.Line,
+			Col:      c.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// conflictFindings reports event values declared with disagreeing hints, once
+// per value at the first declaration, listing every one.
+func conflictFindings(conflicts []Conflict) []Finding {
+	findings := make([]Finding, 0, len(conflicts))
+	for _, cf := range conflicts {
+		first := cf.Consts[0]
+		f := Finding{
+			Category: catHintConflict,
+			Pkg:      first.Pkg,
+			File:     first.Pos.Filename,
+			Line:     first.Pos.Line,
+			Col:      first.Pos.Column,
+		}
+		sites := make([]string, 0, len(cf.Consts))
+		for _, c := range cf.Consts {
+			f.Events = append(f.Events, c.Name)
+			f.DeclaredTypes = append(f.DeclaredTypes, c.Hint)
+			sites = append(sites, fmt.Sprintf("%s wants %s at %s:%d", shortType(c.Name), c.Hint, relPath(c.Pos.Filename), c.Pos.Line))
+		}
+		f.Message = fmt.Sprintf("event %q is declared with conflicting hints: %s", cf.Value, strings.Join(sites, ", "))
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// prefixFindings reports event constants named unlike the rest of their
+// package, or without `-const-prefix` at all.
+func prefixFindings(outliers []PrefixOutlier) []Finding {
+	findings := make([]Finding, 0, len(outliers))
+	for _, o := range outliers {
+		var msg string
+		switch {
+		case o.Prefix != "":
+			msg = fmt.Sprintf("%s starts with %s but the rest of %s start with %s", shortType(o.Name), o.Prefix, o.Pkg, o.Usual)
+		case o.Emitter != "":
+			msg = fmt.Sprintf("%s is bound to emitter %s but doesn't %s", shortType(o.Name), o.Emitter, constNaming())
+		default:
+			msg = fmt.Sprintf("%s doesn't %s", shortType(o.Name), constNaming())
+		}
+		findings = append(findings, Finding{
+			Category: catInconsistentPrefix,
+			Pkg:      o.Pkg,
+			Emitter:  o.Emitter,
+			Events:   []string{o.Name},
+			Message:  msg,
+			File:     o.Pos.Filename,
+			Line:     o.Pos.Line,
+			Col:      o.Pos.Column,
+		})
+	}
+	return findings
+}
+
+// hintProblem says what's wrong with a constant's type hint, if anything:
+// missing, malformed or naming a type that doesn't exist.
+func hintProblem(c EventConst) (category, message string, ok bool) {
+	switch {
+	case noHint(c.Hint):
+		return catMissingHint, fmt.Sprintf("event constant %s has no type hint comment", shortType(c.Name)), true
+	case !hasValidHint(c):
+		return catBadHint, fmt.Sprintf("type hint %q on %s is not a valid type reference", c.Hint, shortType(c.Name)), true
+	case c.Dangling:
+		return catDanglingHint, fmt.Sprintf("type hint %s on %s doesn't name a type", c.Hint, shortType(c.Name)), true
+	}
+	return "", "", false
+}
+
+// diagnosticFindings turns the diagnostics the passes reported, the hint
+// problems and the fact-based mismatches, into findings.  The constants fill in
+// what a diagnostic doesn't carry, matched up by position, and anything already
+// in `have` is skipped since the join usually got there first.
+func diagnosticFindings(graph *checker.Graph, consts []EventConst, have []Finding) []Finding {
+	byPos := make(map[token.Position]EventConst, len(consts))
+	for _, c := range consts {
+		byPos[c.Pos] = c
+		if c.HintPos.IsValid() {
+			byPos[c.HintPos] = c
+		}
+	}
+	type key struct {
+		category string
+		pos      token.Position
+	}
+	seen := make(map[key]bool, len(have))
+	for _, f := range have {
+		seen[key{f.Category, token.Position{Filename: f.File, Line: f.Line, Column: f.Col}}] = true
+	}
+	var findings []Finding
+	for _, act := range graph.Roots {
+		for _, d := range act.Diagnostics {
+			pos := act.Package.Fset.Position(d.Pos)
+			if seen[key{d.Category, token.Position{Filename: pos.Filename, Line: pos.Line, Column: pos.Column}}] {
+				continue
+			}
+			f := Finding{
+				Category: d.Category,
+				Pkg:      act.Package.PkgPath,
+				Message:  d.Message,
+				File:     pos.Filename,
+				Line:     pos.Line,
+				Col:      pos.Column,
+			}
+			if c, ok := byPos[pos]; ok {
+				f.Events = []string{c.Name}
+				if c.Hint != "" {
+					f.DeclaredTypes = []string{c.Hint}
+				}
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// sortFindings puts findings in position order, then category order for
+// findings at the same place, so output is the same from run to run.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Col != b.Col {
+			return a.Col < b.Col
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		// Ties would otherwise come out in whatever order the passes
+		// finished in.
+		if a.Emitter != b.Emitter {
+			return a.Emitter < b.Emitter
+		}
+		return a.Message < b.Message
+	})
+}
+
+// dedupeFindings drops repeats of a finding from sorted findings, the same
+// category, package, emitter, events and position, which is what you get
+// when a decl is seen from several files or a package is in several `-mods`.
+// Call sites all have their own positions so they never collapse.
+func dedupeFindings(sorted []Finding) []Finding {
+	type key struct {
+		category, pkg, emitter, events string
+		file                           string
+		line, col                      int
+	}
+	seen := make(map[key]bool, len(sorted))
+	out := sorted[:0]
+	for _, f := range sorted {
+		k := key{f.Category, f.Pkg, f.Emitter, strings.Join(f.Events, ","), f.File, f.Line, f.Col}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// sortByReason puts findings in reason order, then position order, so the
+// most common resolver gaps clump together.
+func sortByReason(findings []Finding) {
+	sortFindings(findings)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Reason < findings[j].Reason
+	})
+}
+
+// writeReasons is `writeText` with the reason where the category would be,
+// for `-list-unresolved`.
+func writeReasons(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", f.File, f.Line, f.Col, f.Reason, f.Emitter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJoin writes the `-show-join` dry run: the two tables the old shell
+// pipeline built, ET1 being each constant and its hint and ET2 each emitter
+// call's bound event, emitted type and emitter, then the two joined on the
+// event the way `join ET1 ET2` did, with whether the call's type is one the
+// hint accepts.  Everything is sorted so two runs over the same code diff clean.
+func writeJoin(w io.Writer, j Join) error {
+	var et1 []string
+	for name, c := range j.Consts {
+		et1 = append(et1, name+" "+c.Hint)
+	}
+	var et2, joined []string
+	for _, jc := range j.Calls {
+		t := jc.Call.Type
+		if t == "" {
+			t = "-"
+		}
+		emitter := jc.Call.name()
+		for _, ev := range jc.Events {
+			et2 = append(et2, fmt.Sprintf("%s %s %s", ev, t, emitter))
+			accepted, ok := jc.Accept[ev]
+			if !ok {
+				continue
+			}
+			verdict := "no"
+			if accepted {
+				verdict = "ok"
+			}
+			joined = append(joined, fmt.Sprintf("%s %s %s %s %s", ev, j.Consts[ev].Hint, t, emitter, verdict))
+		}
+	}
+	var b strings.Builder
+	for _, table := range []struct {
+		title string
+		rows  []string
+	}{
+		{"ET1 (constant hint)", et1},
+		{"ET2 (event type emitter)", et2},
+		{"join (event hint type emitter match)", joined},
+	} {
+		sort.Strings(table.rows)
+		fmt.Fprintf(&b, "# %s\n", table.title)
+		for _, r := range dedupe(table.rows) {
+			b.WriteString(r + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dedupe drops repeats from a sorted slice, `sort -u` style.
+func dedupe(sorted []string) []string {
+	var out []string
+	for n, s := range sorted {
+		if n == 0 || s != sorted[n-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// writeInventory writes `-list-emitters`: each emitter binding with its event
+// and the type that event's hint says, then each constant with its value and
+// hint, sorted so the output can be checked in and diffed.  `known` is what
+// the bindings get looked up in, dependencies' constants included.
+func writeInventory(w io.Writer, emitters []Emitter, consts, known []EventConst) error {
+	byName := constTable(known)
+	var bound, declared []string
+	for _, e := range emitters {
+		hint := "-"
+		if c, ok := byName[e.Event]; ok && !noHint(c.Hint) {
+			hint = c.resolvedHint()
+		}
+		bound = append(bound, fmt.Sprintf("%s %s %s %s %s:%d", e.Pkg, e.Name, e.Event, hint, relPath(e.Pos.Filename), e.Pos.Line))
+	}
+	for _, c := range consts {
+		hint := c.Hint
+		if noHint(hint) {
+			hint = "-"
+		}
+		declared = append(declared, fmt.Sprintf("%s %s %q %s %s:%d", c.Pkg, c.Name, c.Value, hint, relPath(c.Pos.Filename), c.Pos.Line))
+	}
+	var b strings.Builder
+	for _, table := range []struct {
+		title string
+		rows  []string
+	}{
+		{"emitters (pkg emitter event type position)", bound},
+		{"constants (pkg constant value hint position)", declared},
+	} {
+		sort.Strings(table.rows)
+		fmt.Fprintf(&b, "# %s\n", table.title)
+		for _, r := range dedupe(table.rows) {
+			b.WriteString(r + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeCouplings writes the `-report-cross-package-emissions` view, each
+// constant with its declaring package and then an indented line per package
+// emitting it.
+func writeCouplings(w io.Writer, couplings []Coupling) error {
+	for _, cp := range couplings {
+		if _, err := fmt.Fprintf(w, "%s (%s)\n", shortType(cp.Const.Name), cp.Const.Pkg); err != nil {
+			return err
+		}
+		for _, e := range cp.Emitters {
+			if _, err := fmt.Fprintf(w, "\t%s\t%d\n", e.Pkg, e.Calls); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeCouplingsJSON is `writeCouplings` as one JSON object per constant.
+func writeCouplingsJSON(w io.Writer, couplings []Coupling) error {
+	enc := json.NewEncoder(w)
+	for _, cp := range couplings {
+		err := enc.Encode(struct {
+			Event    string     `json:"event"`
+			Pkg      string     `json:"pkg"`
+			Emitters []PkgCount `json:"emitters"`
+		}{cp.Const.Name, cp.Const.Pkg, cp.Emitters})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record kinds for `-json`, in the order they're written.
+const (
+	recordConst    = "const"
+	recordEmitter  = "emitter"
+	recordCallSite = "callsite"
+	recordMismatch = "mismatch"
+)
+
+var recordOrder = map[string]int{recordConst: 0, recordEmitter: 1, recordCallSite: 2, recordMismatch: 3}
+
+// record is one row of `-json`.  Not every kind fills in every field: a
+// constant has no emitter and a binding has no emitted type, say.
+type record struct {
+	Kind         string `json:"kind"`
+	Pkg          string `json:"pkg"`
+	Emitter      string `json:"emitter,omitempty"`
+	Event        string `json:"event,omitempty"`
+	DeclaredType string `json:"declaredType,omitempty"`
+	EmittedType  string `json:"emittedType,omitempty"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Col          int    `json:"col"`
+}
+
+func newRecord(kind, pkg string, pos token.Position) record {
+	return record{Kind: kind, Pkg: pkg, File: relPath(pos.Filename), Line: pos.Line, Col: pos.Column}
+}
+
+// writeRecords writes `-json`: the constants, bindings and call sites the
+// join worked from and a record per wanted event for each mismatch still in
+// `findings`, as one array.  Files are relative and the order is fixed, kind then position then
+// names, so the output diffs clean between checkouts.
+func writeRecords(w io.Writer, emitters []Emitter, consts []EventConst, calls []CallSite, mismatches []Mismatch, findings []Finding) error {
+	records := []record{}
+	for _, c := range consts {
+		r := newRecord(recordConst, c.Pkg, c.Pos)
+		r.Event, r.DeclaredType = c.Name, c.Hint
+		records = append(records, r)
+	}
+	for _, e := range emitters {
+		r := newRecord(recordEmitter, e.Pkg, e.Pos)
+		r.Emitter, r.Event = e.Name, e.Event
+		records = append(records, r)
+	}
+	for _, call := range calls {
+		r := newRecord(recordCallSite, call.Pkg, call.Pos)
+		r.Emitter, r.EmittedType = call.Recv+"."+call.Emitter, call.Type
+		records = append(records, r)
+	}
+	// The join has the wanted events, a mismatch found by the passes from the
+	// facts only has what the finding says.
+	joined := make(map[token.Position]Mismatch)
+	for _, m := range mismatches {
+		if m.Kind == KindMismatch {
+			joined[m.Call.Pos] = m
+		}
+	}
+	for _, f := range findings {
+		if f.Category != string(KindMismatch) {
+			continue
+		}
+		pos := token.Position{Filename: f.File, Line: f.Line, Column: f.Col}
+		m, ok := joined[pos]
+		if !ok {
+			r := newRecord(recordMismatch, f.Pkg, pos)
+			r.Emitter, r.EmittedType = f.Emitter, f.EmittedType
+			if len(f.Events) == 1 && len(f.DeclaredTypes) == 1 {
+				r.Event, r.DeclaredType = f.Events[0], f.DeclaredTypes[0]
+			}
+			records = append(records, r)
+			continue
+		}
+		for _, want := range m.Wants {
+			r := newRecord(recordMismatch, m.Call.Pkg, m.Call.Pos)
+			r.Emitter, r.EmittedType = m.Call.Recv+"."+m.Call.Emitter, m.Call.Type
+			r.Event, r.DeclaredType = want.Name, want.Hint
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		switch {
+		case a.Kind != b.Kind:
+			return recordOrder[a.Kind] < recordOrder[b.Kind]
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Col != b.Col:
+			return a.Col < b.Col
+		case a.Emitter != b.Emitter:
+			return a.Emitter < b.Emitter
+		case a.Event != b.Event:
+			return a.Event < b.Event
+		}
+		return a.EmittedType < b.EmittedType
+	})
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// writeMarkdown writes a document with a section per emitter: the events it's
+// bound to and their hints, then every call site, linked.  Links are relative
+// to the working directory so the document can be committed next to the code.
+func writeMarkdown(w io.Writer, emitters []Emitter, j Join) error {
+	type key struct{ pkg, name string }
+	var order []key
+	sites := make(map[key][]CallSite)
+	for _, e := range emitters {
+		k := key{e.Pkg, e.Name}
+		if _, ok := sites[k]; !ok {
+			sites[k] = nil
+			order = append(order, k)
+		}
+	}
+	for _, jc := range j.Calls {
+		k := key{jc.Call.Pkg, jc.Call.Emitter}
+		if _, ok := sites[k]; ok {
+			sites[k] = append(sites[k], jc.Call)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].pkg != order[j].pkg {
+			return order[i].pkg < order[j].pkg
+		}
+		return order[i].name < order[j].name
+	})
+
+	var b strings.Builder
+	b.WriteString("# Emitters\n")
+	for _, k := range order {
+		fmt.Fprintf(&b, "\n## `%s` in `%s`\n\n", k.name, k.pkg)
+		b.WriteString("| Event | Value | Type |\n| --- | --- | --- |\n")
+		for _, ev := range j.Events(k.pkg, k.name) {
+			c, ok := j.Consts[ev]
+			hint := "?"
+			if ok && !noHint(c.Hint) {
+				hint = "`" + c.Hint + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", ev, c.Value, hint)
+		}
+		calls := sites[k]
+		if len(calls) == 0 {
+			b.WriteString("\nNever called.\n")
+			continue
+		}
+		b.WriteString("\nCalled from:\n\n")
+		for _, call := range calls {
+			t := "unresolved"
+			if call.Type != "" {
+				t = "`" + shortType(call.Type) + "`"
+			}
+			file := relPath(call.Pos.Filename)
+			fmt.Fprintf(&b, "- [%s:%d](%s#L%d) emits %s\n", file, call.Pos.Line, file, call.Pos.Line, t)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// relPath gives `file` relative to the working directory if it can.
+func relPath(file string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ANSI colours for `writeColor`, by how bad a finding is.
+const (
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorDim    = "\x1b[2m"
+	colorReset  = "\x1b[0m"
+)
+
+// severityColor gives the colour for a severity: red for errors, yellow for
+// warnings and dim for info.
+func severityColor(severity string) string {
+	switch severity {
+	case sevError:
+		return colorRed
+	case sevInfo:
+		return colorDim
+	}
+	return colorYellow
+}
+
+// writeColor is `writeText` with the category coloured by severity, for
+// terminals.
+func writeColor(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s%s%s: %s\n", f.File, f.Line, f.Col, severityColor(f.Severity), f.Category, colorReset, f.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeText writes findings the way compilers and vet do, with the category
+// up front so they can be told apart, `file:line:col: category: message`.
+func writeText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", f.File, f.Line, f.Col, f.Category, f.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes a single JSON document with the findings and any
+// operational errors, so a consumer can tell a clean run from a partial one.
+func writeJSON(w io.Writer, findings []Finding, errs []string) error {
+	envelope := struct {
+		Findings []Finding `json:"findings"`
+		Errors   []string  `json:"errors"`
+	}{
+		Findings: append([]Finding{}, findings...),
+		Errors:   append([]string{}, errs...),
+	}
+	b, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// writeNDJSON writes one JSON object per line per finding for log pipelines.
+// Each line is marshalled - and so complete - on its own.
+func writeNDJSON(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		b, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSeverities(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want map[string]string
+		err  string
+	}{
+		{spec: "", want: map[string]string{}},
+		{spec: "mismatch=warning", want: map[string]string{"mismatch": sevWarning}},
+		{spec: " unresolved=error, ,unused-emitter=info ", want: map[string]string{"unresolved": sevError, "unused-emitter": sevInfo}},
+		{spec: "mismatch", err: `bad -severity "mismatch"`},
+		{spec: "nope=error", err: `unknown -severity category "nope"`},
+		{spec: "mismatch=fatal", err: `unknown severity "fatal" for mismatch`},
+	} {
+		got, err := parseSeverities(tc.spec)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%q: got error %v, want %s", tc.spec, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %v, %v, want %v", tc.spec, got, err, tc.want)
+		}
+	}
+}
+
+// TestSetSeverities checks the defaults, an override of one and that a
+// category with no default is a warning.
+func TestSetSeverities(t *testing.T) {
+	findings := []Finding{
+		{Category: string(KindMismatch)},
+		{Category: string(KindUnresolved)},
+		{Category: catUnusedEmitter},
+		{Category: "no-such-category"},
+	}
+	setSeverities(findings, map[string]string{string(KindUnresolved): sevError})
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Severity)
+	}
+	if want := []string{sevError, sevError, sevInfo, sevWarning}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
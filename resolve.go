@@ -0,0 +1,578 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/pkg/errors"
+)
+
+// unwrapAny strips conversions to an interface, eg. `any(settings)` or
+// `interface{}(settings)`, since the thing being converted is what we're after.
+func unwrapAny(pass *analysis.Pass, e ast.Expr) ast.Expr {
+	for {
+		ce, ok := ast.Unparen(e).(*ast.CallExpr)
+		if !ok || len(ce.Args) != 1 {
+			return e
+		}
+		tv, ok := pass.TypesInfo.Types[ce.Fun]
+		if !ok || !tv.IsType() || !types.IsInterface(tv.Type) {
+			return e
+		}
+		e = ce.Args[0]
+	}
+}
+
+// dynamicType tries to recover the concrete type behind an interface-typed
+// identifier from whatever it was declared with, eg. `var p any = settings`
+// or `p := any(settings)`.
+func dynamicType(pass *analysis.Pass, i *ast.Ident) (string, error) {
+	rhs := declValue(pass, i)
+	if rhs == nil {
+		return "", errNoConcrete
+	}
+	t := pass.TypesInfo.TypeOf(unwrapAny(pass, rhs))
+	if t == nil || types.IsInterface(t) {
+		return "", errNoConcrete
+	}
+	return typeName(t), nil
+}
+
+// instantiations gives what the generic function declaring `tp` has been
+// instantiated with for it, eg. `types.Order` for `emitAll(s, orders)`.  We
+// can only see instantiations in the package being analyzed.
+func instantiations(pass *analysis.Pass, tp *types.TypeParam) []types.Type {
+	var out []types.Type
+	seen := make(map[string]bool)
+	for id, inst := range pass.TypesInfo.Instances {
+		fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+		if !ok {
+			continue
+		}
+		tparams := fn.Type().(*types.Signature).TypeParams()
+		for i := 0; i < tparams.Len() && i < inst.TypeArgs.Len(); i++ {
+			if tparams.At(i) != tp {
+				continue
+			}
+			t := inst.TypeArgs.At(i)
+			if k := typeName(t); !seen[k] {
+				seen[k] = true
+				out = append(out, t)
+			}
+		}
+	}
+	// `Instances` is a map, this keeps the call sites in the same order.
+	sort.Slice(out, func(i, j int) bool {
+		return typeName(out[i]) < typeName(out[j])
+	})
+	return out
+}
+
+// promotedFrom gives the interface a method call is promoted from when the
+// receiver embeds an interface declaring it, eg. `s.userEvent(...)` where `s`
+// embeds `Notifier`.  It's empty for anything else.
+func promotedFrom(pass *analysis.Pass, fun ast.Expr) string {
+	se, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := pass.TypesInfo.Selections[se]
+	if !ok || sel.Kind() != types.MethodVal || len(sel.Index()) < 2 {
+		return ""
+	}
+	fn, ok := sel.Obj().(*types.Func)
+	if !ok {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil || !types.IsInterface(recv.Type()) {
+		return ""
+	}
+	return typeName(recv.Type())
+}
+
+// hintDangles reports whether a `pkg.Type`, `path/to/pkg.Type` or builtin hint
+// names a type that doesn't exist.  We can only tell for the constant's own package and the packages it
+// imports; anything else gets the benefit of the doubt.
+func hintDangles(pass *analysis.Pass, hint string) bool {
+	_, pkg, name := splitType(hint)
+	// A bare name has to be a builtin, `string` say, everything else is
+	// qualified.
+	if pkg == "" {
+		_, ok := types.Universe.Lookup(name).(*types.TypeName)
+		return !ok
+	}
+	if pkg == pass.Pkg.Name() {
+		_, ok := pass.Pkg.Scope().Lookup(name).(*types.TypeName)
+		return !ok
+	}
+	if pkg == pass.Pkg.Path() {
+		_, ok := pass.Pkg.Scope().Lookup(name).(*types.TypeName)
+		return !ok
+	}
+	for _, imp := range pass.Pkg.Imports() {
+		if imp.Name() == pkg || imp.Path() == pkg {
+			_, ok := imp.Scope().Lookup(name).(*types.TypeName)
+			return !ok
+		}
+	}
+	return false
+}
+
+// declValue gives the expression an identifier was declared with, eg. the
+// `rabbitEvents.Default` in `bus := rabbitEvents.Default`, or nil if it wasn't
+// declared with one we can line up.  It goes by the type checker's object
+// and finds where that's declared in this package's files.
+func declValue(pass *analysis.Pass, i *ast.Ident) ast.Expr {
+	obj := pass.TypesInfo.ObjectOf(i)
+	if obj == nil || !obj.Pos().IsValid() {
+		return nil
+	}
+	var file *ast.File
+	for _, f := range pass.Files {
+		if f.FileStart <= obj.Pos() && obj.Pos() < f.FileEnd {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(file, obj.Pos(), obj.Pos())
+	if len(path) < 2 {
+		return nil
+	}
+	if def, ok := path[0].(*ast.Ident); !ok || pass.TypesInfo.Defs[def] != obj {
+		return nil
+	}
+	switch d := path[1].(type) {
+	case *ast.AssignStmt:
+		if len(d.Lhs) != len(d.Rhs) {
+			return nil
+		}
+		for n, l := range d.Lhs {
+			if l == path[0] {
+				return d.Rhs[n]
+			}
+		}
+	case *ast.ValueSpec:
+		for n, name := range d.Names {
+			if name == path[0] && n < len(d.Values) {
+				return d.Values[n]
+			}
+		}
+	}
+	return nil
+}
+
+// isEventsPackage reports whether `p` is the events package, by `-events-pkg`
+// or failing that by name.
+func isEventsPackage(p *types.Package) bool {
+	return p != nil && (p.Path() == *eventsPkg || p.Name() == "rabbitEvents")
+}
+
+// fromEventsPkg reports whether `e` is something in the events package or
+// derived from it, following locals back to what they were declared with, so
+// `bus` counts after `bus := rabbitEvents.Default` and so does a `bus` whose
+// type comes from the events package.  `depth` is how many locals we've
+// followed so far, and past `-resolve-depth` of them we stop with `errDepth`
+// rather than say no.
+func fromEventsPkg(pass *analysis.Pass, e ast.Expr, depth int) (bool, error) {
+	if depth > *resolveDepth {
+		return false, errDepth
+	}
+	switch x := ast.Unparen(e).(type) {
+	case *ast.SelectorExpr:
+		// `d.bus.Emit` where `bus` is an events package type.
+		if isEventsType(pass.TypesInfo.TypeOf(x.X)) {
+			return true, nil
+		}
+		return fromEventsPkg(pass, x.X, depth)
+	case *ast.CallExpr:
+		return fromEventsPkg(pass, x.Fun, depth)
+	case *ast.StarExpr:
+		return fromEventsPkg(pass, x.X, depth)
+	case *ast.Ident:
+		switch obj := pass.TypesInfo.Uses[x].(type) {
+		case *types.PkgName:
+			return isEventsPackage(obj.Imported()), nil
+		case *types.Func:
+			// `Emit(...)` inside the events package or dot-imported.
+			return isEventsPackage(obj.Pkg()), nil
+		case *types.Var:
+			if isEventsType(obj.Type()) {
+				return true, nil
+			}
+			if rhs := declValue(pass, x); rhs != nil {
+				return fromEventsPkg(pass, rhs, depth+1)
+			}
+		}
+	}
+	return false, nil
+}
+
+// isEventsType reports whether `t`, or what it points to, is a named type from
+// the events package.
+func isEventsType(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && isEventsPackage(n.Obj().Pkg())
+}
+
+// isEmitterType reports whether `t` is the events package's `EventEmitter`.
+func isEmitterType(t types.Type) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Name() == "EventEmitter" && isEventsPackage(n.Obj().Pkg())
+}
+
+// chainedEmitter is `selectorParts` for a call whose receiver isn't a plain
+// identifier, eg. `s.WithCtx(ctx).userEvent(...)`.  It only counts if type
+// info says the selector is an emitter field of whatever the chain returns,
+// and the receiver comes back as the chain itself.
+func chainedEmitter(pass *analysis.Pass, fun ast.Expr) (string, string, error) {
+	se, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", errNotSelector
+	}
+	sel, ok := pass.TypesInfo.Selections[se]
+	if !ok || sel.Kind() != types.FieldVal || !isEmitterType(sel.Obj().Type()) {
+		return "", "", errNotEmitter
+	}
+	return types.ExprString(se.X), se.Sel.Name, nil
+}
+
+// forwardsArgs reports whether a call hands on an `args ...interface{}` it was
+// given, `i.e(evt, args...)` in a wrapper's `Emit`.  That's not emitting
+// anything of its own, the wrapper's callers are.  Spreading a slice of
+// something concrete, `s.userEvent(evt, list...)`, is a payload like any other.
+func forwardsArgs(pass *analysis.Pass, ce *ast.CallExpr) bool {
+	if !ce.Ellipsis.IsValid() {
+		return false
+	}
+	s, ok := pass.TypesInfo.TypeOf(ce.Args[len(ce.Args)-1]).(*types.Slice)
+	return ok && types.IsInterface(s.Elem())
+}
+
+// optionsParam reports whether a signature ends in functional options,
+// `opts ...Option` where `Option` is a func type, after the event type and at
+// least one other fixed parameter, and if so how many fixed parameters there
+// are.  A variadic of anything else, `orders ...types.Order`, is a payload.
+func optionsParam(sig *types.Signature) (int, bool) {
+	params := sig.Params()
+	if !sig.Variadic() || params.Len() < 3 {
+		return 0, false
+	}
+	s, ok := params.At(params.Len() - 1).Type().(*types.Slice)
+	if !ok {
+		return 0, false
+	}
+	if _, isFunc := s.Elem().Underlying().(*types.Signature); !isFunc {
+		return 0, false
+	}
+	return params.Len() - 1, true
+}
+
+// paramOf is a function parameter, by position.
+type paramOf struct {
+	fn *types.Func
+	n  int
+}
+
+// parameters indexes the parameters of every function and method this
+// package declares.  Function literals don't count, there's nothing to find
+// the callers of.
+func parameters(pass *analysis.Pass) map[types.Object]paramOf {
+	params := make(map[types.Object]paramOf)
+	for _, file := range pass.Files {
+		for _, d := range file.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			sig := fn.Type().(*types.Signature)
+			for n := 0; n < sig.Params().Len(); n++ {
+				params[sig.Params().At(n)] = paramOf{fn, n}
+			}
+		}
+	}
+	return params
+}
+
+// paramCall is an emitter call whose payload is a parameter of the function
+// it's in, `s.userEvent(evt, payload)` in `func (s *svc) notify(evt
+// rabbitEvents.EventType, payload any)`.  `record` records it as a call site
+// with whatever type turns up, at wherever it's been worked out from.
+type paramCall struct {
+	param  paramOf
+	at     token.Pos
+	record func(t, reason string, at token.Pos)
+}
+
+// resolveParamCall works out a `paramCall`'s payload from what `calls`, the
+// calls to its function, pass for the parameter, with a call site for each at
+// the wrapper's call.  That's one level up and no further: a caller passing
+// on a parameter of its own is left unresolved.  With no callers we can use,
+// the emitter call itself is recorded as unresolved with `reasonParam`.
+func resolveParamCall(pass *analysis.Pass, pc paramCall, calls []*ast.CallExpr) {
+	resolved := false
+	for _, ce := range calls {
+		sig := pc.param.fn.Type().(*types.Signature)
+		if pc.param.n >= len(ce.Args) || (sig.Variadic() && pc.param.n == sig.Params().Len()-1) {
+			continue
+		}
+		arg := unwrapAny(pass, ce.Args[pc.param.n])
+		t := pass.TypesInfo.TypeOf(arg)
+		if t == nil {
+			continue
+		}
+		name := typeName(t)
+		if types.IsInterface(t) {
+			name = ""
+			if i, ok := ast.Unparen(arg).(*ast.Ident); ok {
+				name, _ = dynamicType(pass, i)
+			}
+		}
+		if name == "" {
+			pc.record("", reasonDynamic, ce.Lparen)
+		} else {
+			pc.record(name, "", ce.Lparen)
+		}
+		resolved = true
+	}
+	if !resolved {
+		pc.record("", reasonParam, pc.at)
+	}
+}
+
+// packageEmitter is `selectorParts` for a call straight through a
+// package-level emitter, `userEvent(...)` after
+// `var userEvent = rabbitEvents.Emit(...)`.  The receiver is the package.
+func packageEmitter(pass *analysis.Pass, fun ast.Expr) (string, string, error) {
+	i, ok := fun.(*ast.Ident)
+	if !ok {
+		return "", "", errNotIdent
+	}
+	v, ok := pass.TypesInfo.Uses[i].(*types.Var)
+	if !ok || !isPackageLevel(v) || !isEmitterType(v.Type()) {
+		return "", "", errNotEmitter
+	}
+	return v.Pkg().Name(), i.Name, nil
+}
+
+// localEmitter is `selectorParts` for a call through a local whose type is
+// the events package's `EventEmitter`, `e := s.userEvent` then `e(...)`.  The
+// local's name means nothing to the join so it goes by the field or var it
+// was copied from, and the call is one through that.  If that's further back
+// than `-resolve-depth` it's the local's name with `errDepth`, and no receiver.
+func localEmitter(pass *analysis.Pass, fun ast.Expr) (string, string, error) {
+	o, err := localOrigin(pass, fun)
+	if errors.Is(err, errDepth) {
+		return "", types.ExprString(fun), err
+	}
+	switch o := o.(type) {
+	case *ast.SelectorExpr:
+		fi, fse, err := selectorParts(o)
+		if errors.Is(err, errNotIdent) {
+			return chainedEmitter(pass, o)
+		}
+		return fi, fse, err
+	case *ast.Ident:
+		return packageEmitter(pass, o)
+	}
+	return "", "", errNotEmitter
+}
+
+// localOrigin follows an `EventEmitter` local back through what it was
+// declared with, other locals included, to the field or package-level var
+// it's a copy of.  A parameter, or a local from a call or a constructor, has
+// no origin we can name, and neither does one more than `-resolve-depth`
+// locals back, which is `errDepth`.
+func localOrigin(pass *analysis.Pass, e ast.Expr) (ast.Expr, error) {
+	for depth := 0; ; depth++ {
+		i, ok := ast.Unparen(e).(*ast.Ident)
+		if !ok {
+			return nil, errNotIdent
+		}
+		v, ok := pass.TypesInfo.Uses[i].(*types.Var)
+		if !ok || v.IsField() || isPackageLevel(v) || !isEmitterType(v.Type()) {
+			return nil, errNotEmitter
+		}
+		if depth >= *resolveDepth {
+			return nil, errDepth
+		}
+		if e = declValue(pass, i); e == nil {
+			return nil, errNoConcrete
+		}
+		e = ast.Unparen(e)
+		if emitterVar(pass, e) != nil {
+			return e, nil
+		}
+	}
+}
+
+// eventName gives the name an emitter's event goes by in the inventories, the
+// constant or var qualified by its import path, eg.
+// `github.com/org/types.EventPathUserAccountSettings`, so same-named packages
+// don't collide.  It's `fallback` if `e` isn't one we can look up.
+func eventName(pass *analysis.Pass, e ast.Expr, fallback string) string {
+	if se, ok := ast.Unparen(e).(*ast.SelectorExpr); ok {
+		e = se.Sel
+	}
+	i, ok := ast.Unparen(e).(*ast.Ident)
+	if !ok {
+		return fallback
+	}
+	switch obj := pass.TypesInfo.Uses[i].(type) {
+	case *types.Const:
+		return obj.Pkg().Path() + "." + obj.Name()
+	case *types.Var:
+		if isPackageLevel(obj) {
+			return obj.Pkg().Path() + "." + obj.Name()
+		}
+	}
+	return fallback
+}
+
+// resolveHint qualifies a hint's package by import path, going by the
+// constant's file, so `types.Order` in a file importing `github.com/org/types`
+// is `github.com/org/types.Order` and not any old `types`.  A hint naming an
+// alias resolves to what it's an alias for, since that's what an emitted
+// value's type comes out as.  Builtins and packages the file doesn't import
+// come back as they are.
+func resolveHint(pass *analysis.Pass, file *ast.File, hint string) string {
+	mods, pkg, name := splitType(hint)
+	if pkg == "" {
+		return hint
+	}
+	p := hintPackage(pass, file, pkg)
+	if p == nil {
+		return hint
+	}
+	if tn, ok := p.Scope().Lookup(name).(*types.TypeName); ok && tn.IsAlias() {
+		return mods + typeName(tn.Type())
+	}
+	return mods + p.Path() + "." + name
+}
+
+// hintPackage finds the package a hint's package part means: this one, or one
+// the file imports, by name or by import path.
+func hintPackage(pass *analysis.Pass, file *ast.File, pkg string) *types.Package {
+	if pkg == pass.Pkg.Name() || pkg == pass.Pkg.Path() {
+		return pass.Pkg
+	}
+	imported := func(p string) *types.Package {
+		for _, imp := range pass.Pkg.Imports() {
+			if imp.Path() == p {
+				return imp
+			}
+		}
+		return nil
+	}
+	if strings.Contains(pkg, "/") {
+		return imported(pkg)
+	}
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imp := imported(p)
+		local := path.Base(p)
+		if spec.Name != nil {
+			local = spec.Name.Name
+		} else if imp != nil {
+			local = imp.Name()
+		}
+		if local == pkg {
+			return imp
+		}
+	}
+	return nil
+}
+
+// constObj gives the constant an emitter constructor's argument refers to, eg.
+// `types.EventPathUserAccountSettings`, or nil if it isn't one.
+func constObj(pass *analysis.Pass, e ast.Expr) *types.Const {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.SelectorExpr:
+		e = x.Sel
+	}
+	i, ok := e.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	c, _ := pass.TypesInfo.Uses[i].(*types.Const)
+	return c
+}
+
+// constValue gives the value of a constant expression as type checking worked
+// it out, so `prefix + ".foo"`, `(EventBase)` and another constant all come out
+// as the final string.  Anything that isn't a string, an `iota` say, comes out
+// as Go would write it.
+func constValue(pass *analysis.Pass, e ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil {
+		return "", false
+	}
+	return constString(tv.Value), true
+}
+
+// constString is `constValue` for a value we already have.
+func constString(v constant.Value) string {
+	if v.Kind() == constant.String {
+		return constant.StringVal(v)
+	}
+	return v.ExactString()
+}
+
+// typeName formats a type qualified by its full package path, eg.
+// `github.com/org/rabbitmq.UserSettings`, with any pointer stripped since hints
+// don't bother with them.  `typesMatch` works out how much of the path a hint
+// cares about.
+func typeName(t types.Type) string {
+	t = unalias(t)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	// Anything with a type that didn't check in it is no type at all, not
+	// one called `invalid type` that matches nothing.
+	name := types.TypeString(t, func(p *types.Package) string { return p.Path() })
+	if strings.Contains(name, "invalid type") {
+		return ""
+	}
+	return name
+}
+
+// unalias is `types.Unalias` all the way down through pointers, slices, arrays
+// and maps, so `[]*types.Settings` with `type Settings = UserSettings` comes
+// out as `[]*types.UserSettings`.  An alias is the same type by another name,
+// unlike a defined `type Profile UserSettings`, which stays as it is.
+func unalias(t types.Type) types.Type {
+	switch x := types.Unalias(t).(type) {
+	case *types.Pointer:
+		return types.NewPointer(unalias(x.Elem()))
+	case *types.Slice:
+		return types.NewSlice(unalias(x.Elem()))
+	case *types.Array:
+		return types.NewArray(unalias(x.Elem()), x.Len())
+	case *types.Map:
+		return types.NewMap(unalias(x.Key()), unalias(x.Elem()))
+	default:
+		return x
+	}
+}
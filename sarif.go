@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// categoryDocs is the one line SARIF rules get for each category.
+var categoryDocs = map[string]string{
+	string(KindMismatch):   "The payload matches none of the emitter's events' type hints.",
+	string(KindUnknown):    "The emitter's events have no type hint to check the payload against.",
+	string(KindUnresolved): "The payload's type can't be worked out.",
+	catUnusedEmitter:       "The emitter binding is never called.",
+	catBadHint:             "The type hint isn't a valid type reference.",
+	catMissingHint:         "The event constant has no type hint comment.",
+	catDanglingHint:        "The type hint names a type that doesn't exist.",
+	catDeprecated:          "The call can emit a deprecated event.",
+	catInconsistentPrefix:  "The event constant doesn't have the usual prefix.",
+	catUnwiredEmitter:      "The emitter is never set and will be nil when called.",
+	catHintDrift:           "The type hint has drifted from what gets emitted.",
+	catNaming:              "The event constant's name and value look like different things.",
+	catUnusedEvent:         "The event constant is never emitted.",
+	catUncalledEmitter:     "The emitter is never called.",
+	catHintConflict:        "The same event value is declared with different type hints.",
+	catUnresolvedImpl:      "The call goes through an interface and we can't tell what's behind it.",
+}
+
+// ruleID is a category's SARIF rule id.  Mismatches get a more telling one
+// than the bare category since that's what people will filter on.
+func ruleID(category string) string {
+	if category == string(KindMismatch) {
+		return "emitter-type-mismatch"
+	}
+	return "emitter-" + category
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case sevError:
+		return "error"
+	case sevInfo:
+		return "note"
+	}
+	return "warning"
+}
+
+// The bits of SARIF 2.1.0 we write.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+		HelpURI          string       `json:"helpUri,omitempty"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysical `json:"physicalLocation"`
+	}
+	sarifPhysical struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           sarifRegion   `json:"region"`
+	}
+	sarifArtifact struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// writeSARIF writes the findings as a SARIF 2.1.0 log for code scanning, a
+// rule per category that turns up, in `categories` order.  Files are relative
+// to the working directory, which is `%SRCROOT%` when run from the repo root.
+// Rules only link to docs if `helpURI` is set.
+func writeSARIF(w io.Writer, findings []Finding, helpURI string) error {
+	used := make(map[string]bool)
+	for _, f := range findings {
+		used[f.Category] = true
+	}
+	driver := sarifDriver{Name: "emitteranalysis", Rules: []sarifRule{}}
+	index := make(map[string]int)
+	for _, cat := range categories {
+		if !used[cat] {
+			continue
+		}
+		r := sarifRule{ID: ruleID(cat), ShortDescription: sarifMessage{categoryDocs[cat]}}
+		if helpURI != "" {
+			r.HelpURI = helpURI + "#" + r.ID
+		}
+		index[cat] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, r)
+	}
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		results = append(results, sarifResult{
+			RuleID:    ruleID(f.Category),
+			RuleIndex: index[f.Category],
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysical{
+				ArtifactLocation: sarifArtifact{URI: relPath(f.File), URIBaseID: "%SRCROOT%"},
+				Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Col},
+			}}},
+		})
+	}
+	b, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
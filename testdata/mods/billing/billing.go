@@ -0,0 +1,27 @@
+// Package billing emits the events module's events from a module of its own.
+package billing
+
+import (
+	"example.com/events/rabbitEvents"
+	"example.com/events/types"
+)
+
+type svc struct {
+	invoice rabbitEvents.EventEmitter
+	refund  rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		invoice: rabbitEvents.Emit(types.EventPathInvoice),
+		refund:  rabbitEvents.Emit(types.EventPathRefund),
+	}
+}
+
+func (s *svc) Invoiced(i types.Invoice) error {
+	return s.invoice(rabbitEvents.Create, i)
+}
+
+func (s *svc) Refunded(i types.Invoice) error {
+	return s.refund(rabbitEvents.Create, i)
+}
@@ -0,0 +1,7 @@
+module example.com/billing
+
+go 1.21
+
+require example.com/events v0.0.0
+
+replace example.com/events => ../events
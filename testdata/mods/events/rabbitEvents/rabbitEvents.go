@@ -0,0 +1,12 @@
+// Package rabbitEvents is the events package in a module of its own.
+package rabbitEvents
+
+type EventType string
+
+const Create EventType = "create"
+
+type EventEmitter func(evt EventType, args ...interface{}) error
+
+func Emit(path string) EventEmitter {
+	return func(evt EventType, args ...interface{}) error { return nil }
+}
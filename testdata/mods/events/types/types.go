@@ -0,0 +1,12 @@
+// Package types is the event constants and their payloads, in another module
+// from everything emitting them.
+package types
+
+type Invoice struct{ ID string }
+
+type Refund struct{ ID string }
+
+const (
+	EventPathInvoice = "invoice.sent" // types.Invoice
+	EventPathRefund  = "refund.sent"  // types.Refund
+)
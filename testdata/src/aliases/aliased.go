@@ -0,0 +1,20 @@
+// Package aliases imports the events package and the constants under other
+// names, which are still the same packages.
+package aliases
+
+import (
+	re "rabbitEvents"
+	t "types"
+)
+
+type svc struct {
+	orderEvent re.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{orderEvent: re.Emit(t.EventPathOrder)}
+}
+
+func (s *svc) Order(u t.UserSettings) error {
+	return s.orderEvent(re.Create, u) // finding mismatch `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order` // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
@@ -0,0 +1,18 @@
+package aliases
+
+import (
+	. "rabbitEvents"
+	. "types"
+)
+
+type dotted struct {
+	userEvent EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+}
+
+func newDotted() *dotted {
+	return &dotted{userEvent: Emit(EventPathUserAccountSettings)}
+}
+
+func (d *dotted) Settings(o Order) error {
+	return d.userEvent(Create, o) // finding mismatch `d.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings` // want `d.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
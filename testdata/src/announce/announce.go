@@ -0,0 +1,16 @@
+// Package announce binds package-level emitters for other packages to call.
+package announce
+
+import "rabbitEvents"
+
+type Notice struct{ Text string }
+
+const (
+	EventPathNotice = "notice" // announce.Notice
+	EventPathRecall = "recall" // announce.Notice
+)
+
+// Noticed is only called from `listener`, which still makes it used.
+var Noticed = rabbitEvents.Emit(EventPathNotice)
+
+var Recalled = rabbitEvents.Emit(EventPathRecall) // finding unused-emitter `emitter Recalled is bound to announce.EventPathRecall but never called`
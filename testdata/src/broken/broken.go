@@ -0,0 +1,8 @@
+// Package broken doesn't type check, for the errors that come of that.
+package broken
+
+import "types"
+
+func settings() types.UserSettings {
+	return types.NoSuchType{}
+}
@@ -0,0 +1,42 @@
+// Package bus is an events package of its own, analyzed as one with
+// `-events-pkg bus`, wiring its emitters up without the qualifiers.
+package bus
+
+type EventType string
+
+const Create EventType = "create"
+
+type EventEmitter func(evt EventType, args ...interface{}) error
+
+func Emit(path string) EventEmitter {
+	return func(evt EventType, args ...interface{}) error { return nil }
+}
+
+type Heartbeat struct{ At int64 }
+
+type Shutdown struct{ Reason string }
+
+const (
+	EventPathHeartbeat = "bus.heartbeat" // bus.Heartbeat
+	EventPathShutdown  = "bus.shutdown"  // bus.Shutdown
+)
+
+type monitor struct {
+	heartbeat EventEmitter
+	shutdown  EventEmitter
+}
+
+func newMonitor() *monitor {
+	return &monitor{
+		heartbeat: Emit(EventPathHeartbeat),
+		shutdown:  Emit(EventPathShutdown),
+	}
+}
+
+func (m *monitor) Beat(h Heartbeat) error {
+	return m.heartbeat(Create, h)
+}
+
+func (m *monitor) Stop(h Heartbeat) error {
+	return m.shutdown(Create, h) // finding mismatch `m.shutdown emits bus.Heartbeat but bus.EventPathShutdown wants bus.Shutdown`
+}
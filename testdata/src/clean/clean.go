@@ -0,0 +1,19 @@
+// Package clean emits everything it should, for runs with nothing to say.
+package clean
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{orderEvent: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+func (s *svc) Created(o types.Order) error {
+	return s.orderEvent(rabbitEvents.Create, o)
+}
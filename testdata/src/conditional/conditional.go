@@ -0,0 +1,43 @@
+// Package conditional wires its emitter to different events depending on how
+// it's built, so a call has to match one of them, not all.
+package conditional
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	event rabbitEvents.EventEmitter // want event:`\[types.EventPathOrder .*\]\[types.EventPathUserAccountSettings .*\]`
+}
+
+func newSvc(orders bool) *svc {
+	s := &svc{}
+	if orders {
+		s.event = rabbitEvents.Emit(types.EventPathOrder)
+	} else {
+		s.event = rabbitEvents.Emit(types.EventPathUserAccountSettings)
+	}
+	return s
+}
+
+func newSvcFor(kind string) *svc {
+	switch kind {
+	case "order":
+		return &svc{event: rabbitEvents.Emit(types.EventPathOrder)}
+	default:
+		return &svc{event: rabbitEvents.Emit(types.EventPathUserAccountSettings)}
+	}
+}
+
+func (s *svc) Order(o types.Order) error {
+	return s.event(rabbitEvents.Create, o)
+}
+
+func (s *svc) Settings(u types.UserSettings) error {
+	return s.event(rabbitEvents.Create, u)
+}
+
+func (s *svc) Profile(p types.Profile) error {
+	return s.event(rabbitEvents.Create, p) // finding mismatch `s.event emits types.Profile` // want `s.event emits types.Profile but types.EventPathOrder wants types.Order, types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,13 @@
+// Package config has package-level vars for payloads to come out of.
+package config
+
+import "types"
+
+type defaults struct {
+	Account account
+	Order   types.Order
+}
+
+type account struct{ Settings types.UserSettings }
+
+var Defaults defaults
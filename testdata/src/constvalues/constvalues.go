@@ -0,0 +1,17 @@
+// Package constvalues builds its event values out of other constants, which
+// type checking works out for us.
+package constvalues
+
+type Payment struct{ ID string }
+
+type Refund struct{ ID string }
+
+const prefix = "billing"
+
+const (
+	EventPathPaid     = prefix + ".paid"     // constvalues.Payment // want EventPathPaid:`billing.paid constvalues.Payment`
+	EventPathRefunded = ("billing.refunded") // constvalues.Refund // want EventPathRefunded:`billing.refunded constvalues.Refund`
+	EventPathSettled  = EventPathPaid        // constvalues.Payment // want EventPathSettled:`billing.paid constvalues.Payment`
+	// Not a string, but it's still what events go by.
+	EventCodeChargeback = 1 << 4 // constvalues.Refund // want EventCodeChargeback:`16 constvalues.Refund`
+)
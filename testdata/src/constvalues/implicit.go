@@ -0,0 +1,17 @@
+package constvalues
+
+type Kind int
+
+// Specs with no values repeat the last one's, so each of these is a number of
+// its own, and the repeated string is the same string.  Hints are still each
+// spec's own.
+const (
+	EventKindPaid     Kind = iota + 1 // constvalues.Payment // want EventKindPaid:`1 constvalues.Payment`
+	EventKindRefunded                 // constvalues.Refund // want EventKindRefunded:`2 constvalues.Refund`
+	EventKindVoided                   // constvalues.Refund // want EventKindVoided:`3 constvalues.Refund`
+)
+
+const (
+	EventPathLegacy       = "billing.legacy" // constvalues.Payment // want EventPathLegacy:`billing.legacy constvalues.Payment`
+	EventPathLegacyRefund                    // constvalues.Refund // want EventPathLegacyRefund:`billing.legacy constvalues.Refund`
+)
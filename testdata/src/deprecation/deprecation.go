@@ -0,0 +1,31 @@
+// Package deprecation still emits an event it has marked deprecated, which
+// is nothing the hint can help with.
+package deprecation
+
+import "rabbitEvents"
+
+type Signup struct{ Email string }
+
+const (
+	EventPathSignup       = "signup"        // deprecation.Signup
+	EventPathSignupLegacy = "signup.legacy" // deprecation.Signup (deprecated)
+)
+
+type svc struct {
+	signup rabbitEvents.EventEmitter
+	legacy rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		signup: rabbitEvents.Emit(EventPathSignup),
+		legacy: rabbitEvents.Emit(EventPathSignupLegacy),
+	}
+}
+
+func (s *svc) Signup(u Signup) error {
+	if err := s.signup(rabbitEvents.Create, u); err != nil {
+		return err
+	}
+	return s.legacy(rabbitEvents.Create, u) // finding deprecated-event `s.legacy emits deprecated event deprecation.EventPathSignupLegacy`
+}
@@ -0,0 +1,33 @@
+// Package depth hides its emitters further back than `-resolve-depth 1` looks.
+package depth
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+	userEvent  rabbitEvents.EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+}
+
+func newSvc() *svc {
+	var bus interface {
+		Emit(path string) rabbitEvents.EventEmitter
+	} = rabbitEvents.Default
+	same := bus
+	return &svc{
+		orderEvent: rabbitEvents.Emit(types.EventPathOrder),
+		userEvent:  same.Emit(types.EventPathUserAccountSettings),
+	}
+}
+
+func (s *svc) Created(o types.Order) error {
+	first := s.orderEvent
+	second := first
+	return second(rabbitEvents.Create, o) // finding unresolved `second emits types.Order but we stopped following it back at -resolve-depth, truncated`
+}
+
+func (s *svc) Settings(u types.UserSettings) error {
+	return s.userEvent(rabbitEvents.Create, u) // finding unresolved `s.userEvent emits types.UserSettings but we stopped following it back at -resolve-depth, truncated`
+}
@@ -0,0 +1,44 @@
+// Package drift moved on from `Account` to `Profile` but only told some of
+// its hints.
+package drift
+
+import "rabbitEvents"
+
+type Account struct{ ID string }
+
+type Profile struct{ ID string }
+
+const (
+	EventPathCreated = "created" // drift.Account // finding hint-drift `drift.EventPathCreated wants drift.Account but 2 of 3 calls emit drift.Profile`
+	EventPathUpdated = "updated" // drift.Account
+)
+
+type svc struct {
+	created rabbitEvents.EventEmitter
+	updated rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		created: rabbitEvents.Emit(EventPathCreated),
+		updated: rabbitEvents.Emit(EventPathUpdated),
+	}
+}
+
+func (s *svc) Created(a Account, p Profile) error {
+	if err := s.created(rabbitEvents.Create, a); err != nil {
+		return err
+	}
+	if err := s.created(rabbitEvents.Create, p); err != nil {
+		return err
+	}
+	return s.created(rabbitEvents.Create, p)
+}
+
+// Updated is split down the middle, which isn't drift.
+func (s *svc) Updated(a Account, p Profile) error {
+	if err := s.updated(rabbitEvents.Create, a); err != nil {
+		return err
+	}
+	return s.updated(rabbitEvents.Create, p)
+}
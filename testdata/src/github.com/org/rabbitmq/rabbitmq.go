@@ -0,0 +1,5 @@
+// Package rabbitmq is imported by path, so type info has its types as
+// `github.com/org/rabbitmq.UserSettings` whatever the hints call them.
+package rabbitmq
+
+type UserSettings struct{ Name string }
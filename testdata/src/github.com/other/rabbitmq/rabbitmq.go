@@ -0,0 +1,4 @@
+// Package rabbitmq has the same name and types as the other one, and isn't it.
+package rabbitmq
+
+type UserSettings struct{ Name string }
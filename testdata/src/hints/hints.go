@@ -0,0 +1,23 @@
+// Package hints emits its own event, so it can tell what the hint should be.
+package hints
+
+import "rabbitEvents"
+
+type Payload struct{ ID string }
+
+const (
+	// want +1 "event constant hints.EventPathPayload has no type hint comment" EventPathPayload:"payload.sent"
+	EventPathPayload = "payload.sent"
+)
+
+type svc struct {
+	payloadEvent rabbitEvents.EventEmitter // want payloadEvent:`\[hints.EventPathPayload .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{payloadEvent: rabbitEvents.Emit(EventPathPayload)}
+}
+
+func (s *svc) Sent(p Payload) error {
+	return s.payloadEvent(rabbitEvents.Create, p)
+}
@@ -0,0 +1,27 @@
+// Package impls calls through an interface whose one implementation is
+// declared after the calls, in another file.
+package impls
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type emitter interface {
+	Emit(evt rabbitEvents.EventType, args ...interface{}) error
+}
+
+type svc struct {
+	bus emitter
+}
+
+func newSvc() *svc {
+	return &svc{bus: &settingsBus{}}
+}
+
+func (s *svc) Save(settings types.UserSettings) error {
+	if err := s.bus.Emit(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	return s.bus.Emit(rabbitEvents.Create, types.Order{}) // want `s.bus.Emit emits types.Order but settingsBus.Emit wants types.UserSettings`
+}
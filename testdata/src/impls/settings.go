@@ -0,0 +1,10 @@
+package impls
+
+import "rabbitEvents"
+
+type settingsBus struct{}
+
+// Emit emits types.UserSettings.
+func (*settingsBus) Emit(evt rabbitEvents.EventType, args ...interface{}) error { // want Emit:`\[settingsBus.Emit  types.UserSettings\]`
+	return nil
+}
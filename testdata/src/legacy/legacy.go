@@ -0,0 +1,28 @@
+// Package legacy dot-imports the events package and nothing else, so the
+// constructor, the emitter type and `Create` all come unqualified.
+package legacy
+
+import (
+	. "rabbitEvents"
+	"types"
+)
+
+var orderEvent = Emit(types.EventPathOrder) // want orderEvent:`\[types.EventPathOrder .*\]`
+
+type svc struct {
+	userEvent EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{userEvent: Emit(types.EventPathUserAccountSettings)}
+}
+
+func (s *svc) Place(o types.Order, settings types.UserSettings) error {
+	if err := orderEvent(Create, o); err != nil {
+		return err
+	}
+	if err := orderEvent(Create, settings); err != nil { // finding mismatch `legacy.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order` // want `orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+		return err
+	}
+	return s.userEvent(Create, o) // finding mismatch `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings` // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,11 @@
+// Package listener calls an emitter another package binds.
+package listener
+
+import (
+	"announce"
+	"rabbitEvents"
+)
+
+func Hear(text string) error {
+	return announce.Noticed(rabbitEvents.Create, announce.Notice{Text: text})
+}
@@ -0,0 +1,42 @@
+// Package locals wires its emitters through locals, which count when they
+// came from the events package and don't when they only look like it.
+package locals
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// lookalike has an `Emit` but isn't the events package's.
+type lookalike struct{}
+
+func (lookalike) Emit(path string) rabbitEvents.EventEmitter { return rabbitEvents.Emit(path) }
+
+type svc struct {
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+	userEvent  rabbitEvents.EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+	otherEvent rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	bus := rabbitEvents.Default
+	var typed *rabbitEvents.Bus
+	fake := lookalike{}
+	return &svc{
+		orderEvent: bus.Emit(types.EventPathOrder),
+		userEvent:  typed.Emit(types.EventPathUserAccountSettings),
+		otherEvent: fake.Emit(types.EventPathOrder),
+	}
+}
+
+func (s *svc) Order(o types.Order) error {
+	return s.orderEvent(rabbitEvents.Create, o)
+}
+
+func (s *svc) Settings(o types.Order) error {
+	return s.userEvent(rabbitEvents.Create, o) // finding mismatch `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings` // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
+
+func (s *svc) Other(o types.Order) error {
+	return s.otherEvent(rabbitEvents.Create, o) // finding unknown-event `s.otherEvent emits types.Order but we can't find what it's bound to`
+}
@@ -0,0 +1,16 @@
+// Package multifile binds its emitter in two files, which is one binding
+// with both events, listed in file order, not two that conflict.
+package multifile
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	event rabbitEvents.EventEmitter // want event:`\[types.EventPathUserAccountSettings .*\]\[types.EventPathOrder .*\]`
+}
+
+func newSettingsSvc() *svc {
+	return &svc{event: rabbitEvents.Emit(types.EventPathUserAccountSettings)}
+}
@@ -0,0 +1,22 @@
+package multifile
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+func newOrderSvc() *svc {
+	return &svc{event: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+func (s *svc) Order(o types.Order) error {
+	return s.event(rabbitEvents.Create, o)
+}
+
+func (s *svc) Settings(u types.UserSettings) error {
+	return s.event(rabbitEvents.Create, u)
+}
+
+func (s *svc) Profile(p types.Profile) error {
+	return s.event(rabbitEvents.Create, p) // finding mismatch `s.event emits types.Profile but types.EventPathUserAccountSettings wants types.UserSettings, types.EventPathOrder wants types.Order` // want `s.event emits types.Profile but types.EventPathUserAccountSettings wants types.UserSettings, types.EventPathOrder wants types.Order`
+}
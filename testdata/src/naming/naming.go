@@ -0,0 +1,13 @@
+// Package naming has a constant that was copied and pasted and only half
+// edited.
+package naming
+
+type User struct{ ID string }
+
+type Order struct{ ID string }
+
+const (
+	EventUserCreated  = "user.created"  // naming.User
+	EventOrderCreated = "order_created" // naming.Order
+	EventUserDeleted  = "order.updated" // naming.User // finding naming `naming.EventUserDeleted has value "order.updated", which doesn't look like its name`
+)
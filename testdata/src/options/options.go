@@ -0,0 +1,46 @@
+// Package options wires its emitters through functional options, which only
+// `-option-constructors` can see.
+package options
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	userEvent  rabbitEvents.EventEmitter
+	orderEvent rabbitEvents.EventEmitter
+}
+
+type Option func(*svc)
+
+func WithUserEvent(e rabbitEvents.EventEmitter) Option {
+	return func(s *svc) { s.userEvent = e }
+}
+
+func WithOrders(e rabbitEvents.EventEmitter) Option {
+	return func(s *svc) { s.orderEvent = e }
+}
+
+func newSvc(opts ...Option) *svc {
+	s := &svc{}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+func wire() *svc {
+	return newSvc(
+		WithUserEvent(rabbitEvents.Emit(types.EventPathUserAccountSettings)),
+		WithOrders(rabbitEvents.Emit(types.EventPathOrder)),
+	)
+}
+
+func (s *svc) Settings(u types.UserSettings) error {
+	return s.userEvent(rabbitEvents.Create, u)
+}
+
+func (s *svc) Order(u types.UserSettings) error {
+	return s.orderEvent(rabbitEvents.Create, u) // finding mismatch `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
@@ -0,0 +1,23 @@
+// Package orders owns its events and emits them itself.
+package orders
+
+import "rabbitEvents"
+
+type Order struct{ ID string }
+
+const (
+	EventPathPlaced  = "order.placed"  // orders.Order
+	EventPathShipped = "order.shipped" // orders.Order
+)
+
+type svc struct {
+	placed rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{placed: rabbitEvents.Emit(EventPathPlaced)}
+}
+
+func (s *svc) Place(o Order) error {
+	return s.placed(rabbitEvents.Create, o)
+}
@@ -0,0 +1,9 @@
+// Package pathevents hints at types in packages it doesn't import, so a
+// short-form hint can't be resolved and goes by the package's name.
+package pathevents
+
+const (
+	EventPathShort     = "settings.short"     // rabbitmq.UserSettings
+	EventPathFull      = "settings.full"      // github.com/org/rabbitmq.UserSettings
+	EventPathElsewhere = "settings.elsewhere" // github.com/other/rabbitmq.UserSettings
+)
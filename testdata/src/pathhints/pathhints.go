@@ -0,0 +1,44 @@
+// Package pathhints emits to events with short-form and path-qualified
+// hints, which match on the package name and the whole path respectively.
+package pathhints
+
+import (
+	"pathevents"
+	"rabbitEvents"
+
+	"github.com/org/rabbitmq"
+	other "github.com/other/rabbitmq"
+)
+
+type svc struct {
+	short     rabbitEvents.EventEmitter
+	full      rabbitEvents.EventEmitter
+	elsewhere rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		short:     rabbitEvents.Emit(pathevents.EventPathShort),
+		full:      rabbitEvents.Emit(pathevents.EventPathFull),
+		elsewhere: rabbitEvents.Emit(pathevents.EventPathElsewhere),
+	}
+}
+
+func (s *svc) Settings(u rabbitmq.UserSettings, o other.UserSettings) error {
+	if err := s.short(rabbitEvents.Create, u); err != nil {
+		return err
+	}
+	if err := s.short(rabbitEvents.Create, o); err != nil {
+		return err
+	}
+	if err := s.full(rabbitEvents.Create, u); err != nil {
+		return err
+	}
+	if err := s.full(rabbitEvents.Create, o); err != nil { // finding mismatch `s.full emits github.com/other/rabbitmq.UserSettings but pathevents.EventPathFull wants github.com/org/rabbitmq.UserSettings`
+		return err
+	}
+	if err := s.elsewhere(rabbitEvents.Create, u); err != nil { // finding mismatch `s.elsewhere emits github.com/org/rabbitmq.UserSettings but pathevents.EventPathElsewhere wants github.com/other/rabbitmq.UserSettings`
+		return err
+	}
+	return s.elsewhere(rabbitEvents.Create, o)
+}
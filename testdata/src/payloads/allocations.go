@@ -0,0 +1,19 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Allocations emits what `new` gives back, directly and through a local,
+// which is a pointer to the type and so the type.
+func (s *svc) Allocations() error {
+	if err := s.userEvent(rabbitEvents.Create, new(types.UserSettings)); err != nil {
+		return err
+	}
+	if err := s.orderEvent(rabbitEvents.Create, new(types.Profile)); err != nil { // want `s.orderEvent emits types.Profile but types.EventPathOrder wants types.Order`
+		return err
+	}
+	o := new(types.Order)
+	return s.userEvent(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
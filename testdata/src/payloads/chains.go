@@ -0,0 +1,16 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+func (s *svc) with(tag string) *svc { return s }
+
+// Chains emits through the emitters of whatever a fluent chain returns.
+func (s *svc) Chains(u types.UserSettings) error {
+	if err := s.with("a").with("b").userEvent(rabbitEvents.Create, u); err != nil {
+		return err
+	}
+	return s.with("a").orderEvent(rabbitEvents.Create, u) // want `s.with\("a"\).orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
@@ -0,0 +1,14 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Channels emits straight off channels, which is their element type.
+func (s *svc) Channels(settings <-chan types.UserSettings, orders chan *types.Order) error {
+	if err := s.userEvent(rabbitEvents.Create, <-settings); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, <-orders) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,17 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Conversions emits variables initialised with a conversion, which are the
+// type converted to, not from.
+func (s *svc) Conversions(p types.Profile) error {
+	settings := types.UserSettings(p)
+	if err := s.userEvent(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	back := types.Profile(settings)
+	return s.userEvent(rabbitEvents.Create, back) // want `s.userEvent emits types.Profile but types.EventPathUserAccountSettings wants types.UserSettings`
+}
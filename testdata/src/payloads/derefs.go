@@ -0,0 +1,16 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Derefs emits what pointers point at, from a parameter and from a local
+// taking an address.
+func (s *svc) Derefs(u *types.UserSettings, o types.Order) error {
+	if err := s.userEvent(rabbitEvents.Create, *u); err != nil {
+		return err
+	}
+	p := &o
+	return s.userEvent(rabbitEvents.Create, *p) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,21 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type builders struct {
+	settings func(id string) types.UserSettings
+	order    func() types.Order
+}
+
+// FuncFields emits what functions kept in struct fields return, directly and
+// through a local.
+func (s *svc) FuncFields(b builders, id string) error {
+	if err := s.userEvent(rabbitEvents.Create, b.settings(id)); err != nil {
+		return err
+	}
+	o := b.order()
+	return s.userEvent(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,24 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// emitAll's `item` is whatever it's instantiated with, and each of those is a
+// call of its own.
+func emitAll[T any](s *svc, items []T) error {
+	for _, item := range items {
+		if err := s.userEvent(rabbitEvents.Create, item); err != nil { // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *svc) Generics(settings []types.UserSettings, orders []types.Order) error {
+	if err := emitAll(s, settings); err != nil {
+		return err
+	}
+	return emitAll(s, orders)
+}
@@ -0,0 +1,15 @@
+package payloads
+
+import (
+	"config"
+	"rabbitEvents"
+)
+
+// Globals emits fields off another package's package-level var, which have
+// no declaration here to follow back to.
+func (s *svc) Globals() error {
+	if err := s.userEvent(rabbitEvents.Create, config.Defaults.Account.Settings); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, config.Defaults.Order) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
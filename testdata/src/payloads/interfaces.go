@@ -0,0 +1,26 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Interfaces emits payloads converted to an interface, which we see through
+// as far as what was put in.
+func (s *svc) Interfaces(o types.Order) error {
+	if err := s.orderEvent(rabbitEvents.Create, any(o)); err != nil {
+		return err
+	}
+	if err := s.userEvent(rabbitEvents.Create, interface{}(o)); err != nil { // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+		return err
+	}
+	var p any = types.UserSettings{Name: "x"}
+	if err := s.orderEvent(rabbitEvents.Create, p); err != nil { // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+		return err
+	}
+	// Whatever `lookup` returns, we can't see it.
+	anything := lookup()
+	return s.orderEvent(rabbitEvents.Create, anything) // finding unresolved `s.orderEvent emits a dynamic interface value we can't resolve \(bound to types.EventPathOrder\)`
+}
+
+func lookup() any { return nil }
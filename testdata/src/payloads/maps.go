@@ -0,0 +1,16 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Maps emits what a comma-ok read out of a map gives back, the element and
+// then the bool.
+func (s *svc) Maps(cache map[string]types.UserSettings, id string) error {
+	settings, ok := cache[id]
+	if err := s.userEvent(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	return s.orderEvent(rabbitEvents.Create, ok) // want `s.orderEvent emits bool but types.EventPathOrder wants types.Order`
+}
@@ -0,0 +1,20 @@
+// Package payloads emits its payloads every way we know how to resolve, a
+// file for each, through the one pair of emitters.
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	userEvent  rabbitEvents.EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{
+		userEvent:  rabbitEvents.Emit(types.EventPathUserAccountSettings),
+		orderEvent: rabbitEvents.Emit(types.EventPathOrder),
+	}
+}
@@ -0,0 +1,29 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Ranges emits the loop variable, which is the element type whatever's ranged.
+func (s *svc) Ranges(orders []types.Order, byID map[string]*types.Order, settings chan types.UserSettings) error {
+	for _, o := range orders {
+		if err := s.orderEvent(rabbitEvents.Create, o); err != nil {
+			return err
+		}
+	}
+	for id, o := range byID {
+		if err := s.orderEvent(rabbitEvents.Create, o); err != nil {
+			return err
+		}
+		if err := s.orderEvent(rabbitEvents.Create, id); err != nil { // want `s.orderEvent emits string but types.EventPathOrder wants types.Order`
+			return err
+		}
+	}
+	for u := range settings {
+		if err := s.orderEvent(rabbitEvents.Create, u); err != nil { // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+			return err
+		}
+	}
+	return nil
+}
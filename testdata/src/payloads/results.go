@@ -0,0 +1,26 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+func loadSettings(id string) (types.UserSettings, error) { return types.UserSettings{}, nil }
+
+func loadAll(id string) (types.UserSettings, types.Order, error) {
+	return types.UserSettings{}, types.Order{}, nil
+}
+
+// Results emits variables assigned a call's results, lined up with the left
+// hand side, blanks and all.
+func (s *svc) Results(id string) error {
+	settings, err := loadSettings(id)
+	if err != nil {
+		return err
+	}
+	if err := s.userEvent(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	_, o, _ := loadAll(id)
+	return s.userEvent(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
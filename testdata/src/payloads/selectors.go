@@ -0,0 +1,24 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type account struct {
+	Settings types.UserSettings
+	Order    types.Order
+}
+
+type repo struct{}
+
+func (repo) get(id string) account { return account{} }
+
+// Selectors emits fields off a call's result, which has no declaration to
+// follow back to.
+func (s *svc) Selectors(r repo, id string) error {
+	if err := s.userEvent(rabbitEvents.Create, r.get(id).Settings); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, r.get(id).Order) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
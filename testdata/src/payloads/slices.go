@@ -0,0 +1,18 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Slices emits slice literals, which match on their element type, pointers
+// or not.
+func (s *svc) Slices(a, b types.UserSettings, o types.Order) error {
+	if err := s.userEvent(rabbitEvents.Create, []types.UserSettings{a, b}); err != nil {
+		return err
+	}
+	if err := s.userEvent(rabbitEvents.Create, []*types.UserSettings{&a, &b}); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, []types.Order{o}) // want `s.userEvent emits \[\]types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
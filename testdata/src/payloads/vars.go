@@ -0,0 +1,22 @@
+package payloads
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Vars emits variables declared without an initializer, which are their
+// declared type, and with one and no type, which are the initializer's.
+func (s *svc) Vars(u types.UserSettings) error {
+	var settings types.UserSettings
+	settings.Name = u.Name
+	if err := s.userEvent(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	var copied = u
+	if err := s.orderEvent(rabbitEvents.Create, copied); err != nil { // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+		return err
+	}
+	var order types.Order
+	return s.userEvent(rabbitEvents.Create, order) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
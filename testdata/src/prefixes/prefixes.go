@@ -0,0 +1,26 @@
+// Package prefixes binds whatever `prefixevents` calls its constants.
+package prefixes
+
+import (
+	"prefixevents"
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	createdEvent rabbitEvents.EventEmitter
+	cancelEvent  rabbitEvents.EventEmitter
+	refundEvent  rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		createdEvent: rabbitEvents.Emit(prefixevents.EventOrderCreated),
+		cancelEvent:  rabbitEvents.Emit(prefixevents.EvtOrderCancelled),
+		refundEvent:  rabbitEvents.Emit(prefixevents.TopicOrderRefunded),
+	}
+}
+
+func (s *svc) Refunded(o types.Order) error {
+	return s.refundEvent(rabbitEvents.Create, o)
+}
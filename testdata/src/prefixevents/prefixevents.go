@@ -0,0 +1,9 @@
+// Package prefixevents can't settle on what to call its event constants.
+package prefixevents
+
+const (
+	EventOrderCreated  = "order.created"   // types.Order
+	EventOrderShipped  = "order.shipped"   // types.Order
+	EvtOrderCancelled  = "order.cancelled" // types.Order // finding inconsistent-prefix `prefixevents.EvtOrderCancelled starts with Evt but the rest of prefixevents start with Event`
+	TopicOrderRefunded = "order.refunded"  // types.Order // finding inconsistent-prefix `prefixevents.TopicOrderRefunded is bound to emitter refundEvent but doesn't start with Event or Evt`
+)
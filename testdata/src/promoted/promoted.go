@@ -0,0 +1,41 @@
+// Package promoted calls its emitters through an embedded interface, so
+// they're bound wherever the interface is implemented rather than here.
+package promoted
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// Notifier is what the services are given to emit through.
+type Notifier interface {
+	OrderEvent(evt rabbitEvents.EventType, args ...interface{}) error
+	AuditEvent(evt rabbitEvents.EventType, args ...interface{}) error
+}
+
+// emitters is the implementation's wiring, which is all the join has to go
+// on for `OrderEvent`.
+type emitters struct {
+	OrderEvent rabbitEvents.EventEmitter // want OrderEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newEmitters() *emitters {
+	return &emitters{OrderEvent: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+type svc struct {
+	Notifier
+}
+
+func (s *svc) Created(o types.Order) error {
+	return s.OrderEvent(rabbitEvents.Create, o)
+}
+
+func (s *svc) Settings(u types.UserSettings) error {
+	return s.OrderEvent(rabbitEvents.Create, u) // finding mismatch `s.OrderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
+
+// Audited goes through a method nothing we've loaded binds.
+func (s *svc) Audited(o types.Order) error {
+	return s.AuditEvent(rabbitEvents.Create, o) // finding unknown-event `s.AuditEvent emits types.Order through promoted.Notifier but we can't find what it's bound to`
+}
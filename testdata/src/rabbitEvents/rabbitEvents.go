@@ -0,0 +1,28 @@
+// Package rabbitEvents is just enough of the real thing to wire emitters up.
+package rabbitEvents
+
+type EventType string
+
+const Create EventType = "create"
+
+type EventEmitter func(evt EventType, args ...interface{}) error
+
+func Emit(path string) EventEmitter {
+	return func(evt EventType, args ...interface{}) error { return nil }
+}
+
+// Option tweaks a single emission, it's never the payload.
+type Option func(*options)
+
+type options struct{ retries int }
+
+func WithRetries(n int) Option {
+	return func(o *options) { o.retries = n }
+}
+
+// Bus hands out emitters like `Emit` does, for wiring through a value.
+type Bus struct{}
+
+func (*Bus) Emit(path string) EventEmitter { return Emit(path) }
+
+var Default = &Bus{}
@@ -0,0 +1,36 @@
+// Package reasons has a payload we can't resolve for each reason we give.
+package reasons
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	orderEvent rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{orderEvent: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+func lookup() any { return nil }
+
+func (s *svc) Dynamic() error {
+	v := lookup()
+	return s.orderEvent(rabbitEvents.Create, v)
+}
+
+// Param is never called, so nothing says what `v` is.
+func (s *svc) Param(v interface{ ID() string }) error {
+	return s.orderEvent(rabbitEvents.Create, v)
+}
+
+// send is never instantiated.
+func send[T any](s *svc, v T) error {
+	return s.orderEvent(rabbitEvents.Create, v)
+}
+
+func (s *svc) NoPayload() error {
+	return s.orderEvent(rabbitEvents.Create)
+}
@@ -0,0 +1,31 @@
+// Package reportall has one of everything `-report-all` adds.
+package reportall
+
+import "rabbitEvents"
+
+type Ping struct{}
+
+const (
+	EventPathPing = "ping" // reportall.Ping
+	EventPathPong = "pong" // not a type at all // finding bad-hint `type hint "not a type at all" on reportall.EventPathPong is not a valid type reference`
+	EventPathEcho = "echo" // reportall.Echo // finding dangling-hint `type hint reportall.Echo on reportall.EventPathEcho doesn't name a type`
+	// Anything after the value would be a hint, so the marker goes before it.
+	EventPathSilence = // finding missing-hint `event constant reportall.EventPathSilence has no type hint comment`
+	"silence"
+)
+
+type svc struct {
+	ping  rabbitEvents.EventEmitter
+	quiet rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		ping:  rabbitEvents.Emit(EventPathPing),
+		quiet: rabbitEvents.Emit(EventPathSilence), // finding unused-emitter `emitter quiet is bound to reportall.EventPathSilence but never called`
+	}
+}
+
+func (s *svc) Ping() error {
+	return s.ping(rabbitEvents.Create, Ping{})
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type aliasSvc struct {
+	userEvent  rabbitEvents.EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+	aliasEvent rabbitEvents.EventEmitter // want aliasEvent:`\[types.EventPathSettingsAlias .* types.Settings\]`
+}
+
+func newAliasSvc() *aliasSvc {
+	return &aliasSvc{
+		userEvent:  rabbitEvents.Emit(types.EventPathUserAccountSettings),
+		aliasEvent: rabbitEvents.Emit(types.EventPathSettingsAlias),
+	}
+}
+
+// Alias emits through the alias, which is the same type as far as Go's
+// concerned.
+func (s *aliasSvc) Alias() error {
+	if err := s.userEvent(rabbitEvents.Create, types.Settings{Name: "x"}); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, []*types.Settings{})
+}
+
+// AliasHint is the other way round, the hint names the alias.
+func (s *aliasSvc) AliasHint() error {
+	return s.aliasEvent(rabbitEvents.Create, types.UserSettings{Name: "x"})
+}
+
+// Defined is a type of its own however alike it looks.
+func (s *aliasSvc) Defined() error {
+	return s.userEvent(rabbitEvents.Create, types.Profile{Name: "x"}) // want `s.userEvent emits types.Profile but types.EventPathUserAccountSettings wants types.UserSettings`
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// baseService carries the emitter, the services built on it just call it.
+type baseService struct {
+	auditEvent rabbitEvents.EventEmitter // want auditEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newBaseService() baseService {
+	return baseService{auditEvent: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+// accountService gets the emitter from one level down.
+type accountService struct {
+	baseService
+}
+
+// billingService gets it from two.
+type billingService struct {
+	*accountService
+}
+
+func newBillingService() *billingService {
+	return &billingService{accountService: &accountService{baseService: newBaseService()}}
+}
+
+// OneLevel goes through the embedded `baseService`.
+func (s *accountService) OneLevel(o types.Order) error {
+	return s.auditEvent(rabbitEvents.Create, o)
+}
+
+// TwoLevels goes through `accountService` and then `baseService`.
+func (s *billingService) TwoLevels(settings types.UserSettings) error {
+	return s.auditEvent(rabbitEvents.Create, settings) // want `s.auditEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
+
+// Spelled reaches the same emitter with the embedding written out.
+func (s *billingService) Spelled(settings types.UserSettings) error {
+	return s.accountService.baseService.auditEvent(rabbitEvents.Create, settings) // want `auditEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
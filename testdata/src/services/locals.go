@@ -0,0 +1,26 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// ViaLocal calls the emitter through a copy, which goes by its type rather
+// than its name.
+func (s *svc) ViaLocal(o types.Order) error {
+	emit := s.userEvent
+	return emit(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
+
+// ViaLocals follows the copy of a copy back to the field.
+func (s *svc) ViaLocals(o types.Order) error {
+	var first rabbitEvents.EventEmitter = s.orderEvent
+	second := first
+	return second(rabbitEvents.Create, o)
+}
+
+// ViaAliasedReceiver calls it through another name for `s`.
+func (s *svc) ViaAliasedReceiver(settings types.UserSettings) error {
+	other := s
+	return other.orderEvent(rabbitEvents.Create, settings) // want `other.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
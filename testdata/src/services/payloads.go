@@ -0,0 +1,50 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+// The payload's type comes from type info, so every form of expression
+// resolves the same way an identifier does.
+
+func newSettings(name string) *types.UserSettings {
+	return &types.UserSettings{Name: name}
+}
+
+// Composite is an inline literal.
+func (s *svc) Composite() error {
+	if err := s.userEvent(rabbitEvents.Create, types.UserSettings{Name: "x"}); err != nil {
+		return err
+	}
+	return s.orderEvent(rabbitEvents.Create, types.UserSettings{Name: "x"}) // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
+
+// AddressOf is `&` on a literal and on a variable, hints don't care about
+// the pointer.
+func (s *svc) AddressOf(o types.Order) error {
+	if err := s.userEvent(rabbitEvents.Create, &types.UserSettings{Name: "x"}); err != nil {
+		return err
+	}
+	if err := s.orderEvent(rabbitEvents.Create, &o); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, &o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
+
+// Call emits a function's result directly.
+func (s *svc) Call() error {
+	if err := s.userEvent(rabbitEvents.Create, newSettings("x")); err != nil {
+		return err
+	}
+	return s.orderEvent(rabbitEvents.Create, newSettings("x")) // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
+
+// Ident is the plain variable, for comparison.
+func (s *svc) Ident() error {
+	o := types.Order{ID: "x"}
+	if err := s.orderEvent(rabbitEvents.Create, o); err != nil {
+		return err
+	}
+	return s.userEvent(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
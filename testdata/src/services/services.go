@@ -0,0 +1,51 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	userEvent  rabbitEvents.EventEmitter // want userEvent:`\[types.EventPathUserAccountSettings .*\]`
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+	// Never bound, so there's nothing to check calls through it against.
+	otherEvent rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		userEvent:  rabbitEvents.Emit(types.EventPathUserAccountSettings),
+		orderEvent: rabbitEvents.Emit(types.EventPathOrder),
+	}
+}
+
+func (s *svc) updateUserSettings(name string) (types.UserSettings, error) {
+	return types.UserSettings{Name: name}, nil
+}
+
+// Match is fine.
+func (s *svc) Match(settings types.UserSettings) error {
+	return s.userEvent(rabbitEvents.Create, settings)
+}
+
+// Mismatch emits an order through the settings emitter.
+func (s *svc) Mismatch(o types.Order) error {
+	return s.userEvent(rabbitEvents.Create, o) // want `s.userEvent emits types.Order but types.EventPathUserAccountSettings wants types.UserSettings`
+}
+
+// Unknown goes through an emitter that's never bound.
+func (s *svc) Unknown(o types.Order) error {
+	return s.otherEvent(rabbitEvents.Create, o) // finding unknown-event `s.otherEvent emits types.Order but we can't find what it's bound to`
+}
+
+// MultiReturn emits the first result of a two-result call.
+func (s *svc) MultiReturn() error {
+	settings, err := s.updateUserSettings("x")
+	if err != nil {
+		return err
+	}
+	if err := s.userEvent(rabbitEvents.Create, settings); err != nil {
+		return err
+	}
+	return s.orderEvent(rabbitEvents.Create, settings) // want `s.orderEvent emits types.UserSettings but types.EventPathOrder wants types.Order`
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type retrying struct{}
+
+// retryEvent emits types.Order.  It takes functional options after the
+// payload, which are no part of what it emits.
+func (r *retrying) retryEvent(evt rabbitEvents.EventType, payload interface{}, opts ...rabbitEvents.Option) error { // want retryEvent:`\[retrying.retryEvent +types.Order\]`
+	return nil
+}
+
+// WithOptions is fine, the options aren't the payload.
+func (r *retrying) WithOptions(o types.Order) error {
+	return r.retryEvent(rabbitEvents.Create, o, rabbitEvents.WithRetries(3))
+}
+
+// WrongWithOptions sends settings however many options come after them.
+func (r *retrying) WrongWithOptions(settings types.UserSettings) error {
+	return r.retryEvent(rabbitEvents.Create, settings, rabbitEvents.WithRetries(3), rabbitEvents.WithRetries(1)) // want `r.retryEvent emits types.UserSettings but retrying.retryEvent wants types.Order`
+}
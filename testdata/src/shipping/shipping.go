@@ -0,0 +1,22 @@
+// Package shipping emits an event `orders` owns, which couples the two.
+package shipping
+
+import (
+	"orders"
+	"rabbitEvents"
+)
+
+type svc struct {
+	shipped rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{shipped: rabbitEvents.Emit(orders.EventPathShipped)}
+}
+
+func (s *svc) Ship(o orders.Order) error {
+	if err := s.shipped(rabbitEvents.Create, o); err != nil {
+		return err
+	}
+	return s.shipped(rabbitEvents.Create, o)
+}
@@ -0,0 +1,42 @@
+// Package stdhints carries builtins and standard library types, which are
+// hints like any other.
+package stdhints
+
+import (
+	"rabbitEvents"
+	"time"
+)
+
+const (
+	EventPathName = "name" // string
+	EventPathBody = "body" // []byte
+	EventPathAt   = "at"   // time.Time
+	EventPathTypo = "typo" // strng // finding dangling-hint `type hint strng on stdhints.EventPathTypo doesn't name a type`
+)
+
+type svc struct {
+	name rabbitEvents.EventEmitter
+	body rabbitEvents.EventEmitter
+	at   rabbitEvents.EventEmitter
+}
+
+func newSvc() *svc {
+	return &svc{
+		name: rabbitEvents.Emit(EventPathName),
+		body: rabbitEvents.Emit(EventPathBody),
+		at:   rabbitEvents.Emit(EventPathAt),
+	}
+}
+
+func (s *svc) Send(name string, body []byte, at time.Time, d time.Duration) error {
+	if err := s.name(rabbitEvents.Create, name); err != nil {
+		return err
+	}
+	if err := s.body(rabbitEvents.Create, body); err != nil {
+		return err
+	}
+	if err := s.at(rabbitEvents.Create, at); err != nil {
+		return err
+	}
+	return s.at(rabbitEvents.Create, d) // finding mismatch `s.at emits time.Duration but stdhints.EventPathAt wants time.Time`
+}
@@ -0,0 +1,18 @@
+package types
+
+type UserSettings struct{ Name string }
+
+type Order struct{ ID string }
+
+const (
+	EventPathUserAccountSettings = "user.account.settings" // types.UserSettings // want EventPathUserAccountSettings:`user.account.settings types.UserSettings`
+	EventPathOrder               = "order.created"         // types.Order // want EventPathOrder:`order.created types.Order`
+)
+
+// Settings is another name for `UserSettings`, so it satisfies the same hints.
+type Settings = UserSettings
+
+// Profile only has the same underlying type, it's a different type.
+type Profile UserSettings
+
+const EventPathSettingsAlias = "user.settings.alias" // types.Settings // want EventPathSettingsAlias:`user.settings.alias types.Settings`
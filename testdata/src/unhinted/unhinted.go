@@ -0,0 +1,5 @@
+// Package unhinted has an event constant with nothing to say about itself,
+// no doc and no comment.
+package unhinted
+
+const EventPathBare = "bare"
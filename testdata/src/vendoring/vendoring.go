@@ -0,0 +1,22 @@
+// Package vendoring has a vendored dependency, which isn't ours to report on
+// unless we're asked to.
+package vendoring
+
+import (
+	"acme"
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	client     *acme.Client
+	orderEvent rabbitEvents.EventEmitter // want orderEvent:`\[types.EventPathOrder .*\]`
+}
+
+func newSvc() *svc {
+	return &svc{client: acme.New(), orderEvent: rabbitEvents.Emit(types.EventPathOrder)}
+}
+
+func (s *svc) Created(o types.Order) error {
+	return s.orderEvent(rabbitEvents.Create, o)
+}
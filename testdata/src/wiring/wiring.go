@@ -0,0 +1,23 @@
+// Package wiring sets its emitter fields every way there is, but one.
+package wiring
+
+import (
+	"rabbitEvents"
+	"types"
+)
+
+type svc struct {
+	orderEvent rabbitEvents.EventEmitter
+	userEvent  rabbitEvents.EventEmitter
+	auditEvent rabbitEvents.EventEmitter // finding unwired-emitter `emitter field auditEvent is never set and will be nil when called`
+}
+
+func newSvc() *svc {
+	s := &svc{orderEvent: rabbitEvents.Emit(types.EventPathOrder)}
+	s.userEvent = rabbitEvents.Emit(types.EventPathUserAccountSettings)
+	return s
+}
+
+func (s *svc) Audit(o types.Order) error {
+	return s.auditEvent(rabbitEvents.Create, o)
+}
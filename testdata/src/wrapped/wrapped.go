@@ -0,0 +1,46 @@
+// Package wrapped is an events package whose events are structs rather than
+// strings, the string being `Meta.Name`, for `-event-value-field Meta.Name`.
+package wrapped
+
+type Meta struct{ Name string }
+
+type Event struct{ Meta Meta }
+
+type EventType string
+
+const Create EventType = "create"
+
+type EventEmitter func(evt EventType, args ...interface{}) error
+
+func Emit(e Event) EventEmitter {
+	return func(evt EventType, args ...interface{}) error { return nil }
+}
+
+type Receipt struct{ ID string }
+
+type Refund struct{ ID string }
+
+var (
+	EventPathReceipt = Event{Meta: Meta{Name: "receipt.sent"}}  // wrapped.Receipt
+	EventPathRefund  = Event{Meta: Meta{Name: "refund.issued"}} // wrapped.Refund
+)
+
+type billing struct {
+	receipt EventEmitter
+	refund  EventEmitter
+}
+
+func newBilling() *billing {
+	return &billing{
+		receipt: Emit(EventPathReceipt),
+		refund:  Emit(EventPathRefund),
+	}
+}
+
+func (b *billing) Sent(r Receipt) error {
+	return b.receipt(Create, r)
+}
+
+func (b *billing) Refunded(r Receipt) error {
+	return b.refund(Create, r) // finding mismatch `b.refund emits wrapped.Receipt but wrapped.EventPathRefund wants wrapped.Refund`
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/tools/go/analysis"
+)
+
+// tuiContext is how many lines either side of a finding we show.
+const tuiContext = 3
+
+// Marks a finding can be given during review.
+const (
+	markNone   = ""
+	markFix    = "fix"
+	markIgnore = "ignore"
+)
+
+// reviewModel steps through findings one at a time so they can be marked for
+// fixing or ignoring.  The marks get acted on when we quit, see `save`.
+type reviewModel struct {
+	findings []Finding
+	fixes    []*analysis.SuggestedFix // by finding, nil where there's nothing to apply
+	marks    []string
+	cursor   int
+	status   string // what the last key did, if it needs saying
+	sources  map[string][]string
+}
+
+func newReviewModel(findings []Finding, fixes []*analysis.SuggestedFix) reviewModel {
+	return reviewModel{
+		findings: findings,
+		fixes:    fixes,
+		marks:    make([]string, len(findings)),
+		sources:  make(map[string][]string),
+	}
+}
+
+func (m reviewModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.status = ""
+	switch k.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "down", "j", "n":
+		if m.cursor < len(m.findings)-1 {
+			m.cursor++
+		}
+	case "up", "k", "p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "f":
+		if m.fix() == nil {
+			m.status = "nothing to fix here, we only know how to add inferred type hints"
+			break
+		}
+		m.mark(markFix)
+	case "i":
+		m.mark(markIgnore)
+	case "u", " ":
+		m.mark(markNone)
+	}
+	return m, nil
+}
+
+// fix gives the current finding's fix, if it has one.
+func (m reviewModel) fix() *analysis.SuggestedFix {
+	if m.cursor >= len(m.fixes) {
+		return nil
+	}
+	return m.fixes[m.cursor]
+}
+
+// mark sets the current finding's mark and moves on to the next one.
+func (m *reviewModel) mark(mark string) {
+	if len(m.findings) == 0 {
+		return
+	}
+	m.marks[m.cursor] = mark
+	if mark != markNone && m.cursor < len(m.findings)-1 {
+		m.cursor++
+	}
+}
+
+func (m reviewModel) View() string {
+	if len(m.findings) == 0 {
+		return "No findings.  q to quit.\n"
+	}
+	f := m.findings[m.cursor]
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%d/%d] %s", m.cursor+1, len(m.findings), f.Category)
+	if mark := m.marks[m.cursor]; mark != markNone {
+		fmt.Fprintf(&b, " (%s)", mark)
+	}
+	fmt.Fprintf(&b, "\n%s:%d:%d\n%s\n\n", f.File, f.Line, f.Col, f.Message)
+	lines := m.source(f.File)
+	for n := f.Line - tuiContext; n <= f.Line+tuiContext; n++ {
+		if n < 1 || n > len(lines) {
+			continue
+		}
+		cur := " "
+		if n == f.Line {
+			cur = ">"
+		}
+		fmt.Fprintf(&b, "%s %5d  %s\n", cur, n, lines[n-1])
+	}
+	if m.status != "" {
+		fmt.Fprintf(&b, "\n%s\n", m.status)
+	}
+	keys := "j/k move  i ignore  u unmark  q quit"
+	if m.fix() != nil {
+		keys = "j/k move  f fix  i ignore  u unmark  q quit"
+	}
+	b.WriteString("\n" + keys + "\n")
+	return b.String()
+}
+
+// source returns the lines of a file, reading it the first time it's asked for.
+// The map is shared between copies of the model so this sticks.
+func (m reviewModel) source(file string) []string {
+	if lines, ok := m.sources[file]; ok {
+		return lines
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		b = []byte(err.Error())
+	}
+	lines := strings.Split(string(b), "\n")
+	m.sources[file] = lines
+	return lines
+}
+
+// ignored gives the findings marked to be ignored.
+func (m reviewModel) ignored() []Finding {
+	var out []Finding
+	for n, f := range m.findings {
+		if m.marks[n] == markIgnore {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// fixing gives the fixes for the findings marked to be fixed.
+func (m reviewModel) fixing() []analysis.SuggestedFix {
+	var out []analysis.SuggestedFix
+	for n := range m.findings {
+		if m.marks[n] == markFix && n < len(m.fixes) && m.fixes[n] != nil {
+			out = append(out, *m.fixes[n])
+		}
+	}
+	return out
+}
+
+// save does what the marks say: applies the fixes and adds the ignored
+// findings to the baseline at `path`, which needn't exist yet, so a run with
+// `-baseline` doesn't report them again.
+func (m reviewModel) save(fset *token.FileSet, path string) error {
+	if fixes := m.fixing(); len(fixes) > 0 {
+		if _, err := applyFixes(fset, fixes); err != nil {
+			return err
+		}
+	}
+	ignored := m.ignored()
+	if len(ignored) == 0 {
+		return nil
+	}
+	b, err := readBaseline(path)
+	if os.IsNotExist(err) {
+		b, err = make(baseline), nil
+	}
+	if err != nil {
+		return err
+	}
+	b.add(ignored)
+	return writeFile(path, func(w io.Writer, _ []Finding) error { return b.write(w) }, nil)
+}
+
+// review runs the TUI over the findings, `fixes` being what `f` applies to
+// each, and then saves what was marked.
+func review(findings []Finding, fixes []*analysis.SuggestedFix, fset *token.FileSet, path string) error {
+	final, err := tea.NewProgram(newReviewModel(findings, fixes), tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+	return final.(reviewModel).save(fset, path)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/tools/go/analysis"
+)
+
+// press feeds the model keys the way bubbletea would.
+func press(m reviewModel, keys ...string) reviewModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, _ := m.Update(msg)
+		m = next.(reviewModel)
+	}
+	return m
+}
+
+func TestReviewModel(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "events.go")
+	if err := os.WriteFile(src, []byte("package events\n\nconst EventOrder = \"order\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	findings := []Finding{
+		{Category: string(KindMismatch), Pkg: "services", Emitter: "s.orderEvent", EmittedType: "types.User", Message: "s.orderEvent emits types.User but types.EventPathOrder wants types.Order", File: src, Line: 3, Col: 1},
+		{Category: catMissingHint, Pkg: "events", Events: []string{"events.EventOrder"}, Message: "events.EventOrder has no type hint", File: src, Line: 3, Col: 7},
+	}
+	fixes := []*analysis.SuggestedFix{nil, {Message: "add type hint types.Order to events.EventOrder"}}
+
+	m := newReviewModel(findings, fixes)
+	if cmd := m.Init(); cmd != nil {
+		t.Errorf("Init gave a command, want nil")
+	}
+	view := m.View()
+	for _, want := range []string{"[1/2] mismatch", "emits types.User", `>     3  const EventOrder = "order"`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("first view has no %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "f fix") {
+		t.Errorf("first view offers a fix it hasn't got:\n%s", view)
+	}
+
+	// Nothing to fix on the first, so `f` stays put and says so.
+	m = press(m, "f")
+	if m.cursor != 0 || m.marks[0] != markNone || !strings.Contains(m.View(), "nothing to fix") {
+		t.Errorf("f on a finding without a fix: cursor %d, mark %q", m.cursor, m.marks[0])
+	}
+	m = press(m, "i", "f")
+	if m.marks[0] != markIgnore || m.marks[1] != markFix {
+		t.Errorf("marks are %q, want ignore then fix", m.marks)
+	}
+	if got := m.ignored(); len(got) != 1 || got[0].Category != string(KindMismatch) {
+		t.Errorf("ignored gave %+v", got)
+	}
+	if got := m.fixing(); len(got) != 1 || got[0].Message != fixes[1].Message {
+		t.Errorf("fixing gave %+v", got)
+	}
+	m = press(m, "up", "u")
+	if m.marks[0] != markNone {
+		t.Errorf("u left mark %q", m.marks[0])
+	}
+
+	if next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Errorf("q didn't quit")
+	} else if _, ok := next.(reviewModel); !ok {
+		t.Errorf("q gave back a %T", next)
+	}
+}
+
+func TestReviewSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	f := Finding{Category: string(KindMismatch), Pkg: "services", Emitter: "s.orderEvent", EmittedType: "types.User"}
+	m := press(newReviewModel([]Finding{f}, nil), "i")
+	// Twice, so the second has a baseline to add to.
+	for n := 0; n < 2; n++ {
+		if err := m.save(token.NewFileSet(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := readBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[fingerprint(f)] != 2 {
+		t.Errorf("baseline is %v, want %s twice", b, fingerprint(f))
+	}
+}